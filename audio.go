@@ -2,40 +2,60 @@ package coffeebeanbot
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
 	"log"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
 )
 
-func (bot *Bot) playEndSound(notif pomodoro.NotifyInfo) error {
-	// Find the user in the voice chat for the guild
-	voiceChannelID := findUserVoiceChannelID(bot.discord, notif.GuildID, notif.UserID)
-	if voiceChannelID == "" {
+// playEndSound plays the end sound for everyone taking part in the Pomodoro. See playSoundForUsers.
+func (bot *Bot) playEndSound(notif NotifyInfo) error {
+	return bot.playSoundForUsers(notif, bot.workEndAudioBuffer)
+}
+
+// playSoundForUsers plays the audio in each voice channel that someone taking part in the Pomodoro is in. We can only be
+// in one voice channel per Guild at a time, so it is played in one after another.
+func (bot *Bot) playSoundForUsers(notif NotifyInfo, audioBuffer [][]byte) error {
+	// Simply don't join any voice channels if there's nothing to play, or it's a DM Pomodoro which has no voice channels
+	if audioBuffer == nil || notif.GuildID == "" {
 		return nil
 	}
 
-	return playSound(bot.discord, notif.GuildID, voiceChannelID, bot.workEndAudioBuffer)
+	var errs []error
+	for _, voiceChannelID := range findUserVoiceChannelIDs(bot.discord, notif.GuildID, notif.Users()) {
+		errs = append(errs, playSound(bot.discord, notif.GuildID, voiceChannelID, audioBuffer))
+	}
+
+	return errors.Join(errs...)
 }
 
-func findUserVoiceChannelID(s *discordgo.Session, guildID, userID string) string {
-	channelID := ""
-	guild, err := s.Guild(guildID)
+// findUserVoiceChannelIDs returns the distinct voice channels the users are in, in the order of the users given.
+func findUserVoiceChannelIDs(s *discordgo.Session, guildID string, userIDs []string) []string {
+	guild, err := s.State.Guild(guildID)
 	if err != nil {
-		return channelID
+		return nil
 	}
 
-	for _, voiceState := range guild.VoiceStates {
-		if voiceState.UserID == userID {
-			channelID = voiceState.ChannelID
-			break
+	return voiceChannelIDs(guild.VoiceStates, userIDs)
+}
+
+// voiceChannelIDs returns the distinct channels in the voice states that the users are in, in the order of the users given.
+func voiceChannelIDs(voiceStates []*discordgo.VoiceState, userIDs []string) []string {
+	var channelIDs []string
+	for _, userID := range userIDs {
+		for _, voiceState := range voiceStates {
+			if voiceState.UserID == userID && !slices.Contains(channelIDs, voiceState.ChannelID) {
+				channelIDs = append(channelIDs, voiceState.ChannelID)
+				break
+			}
 		}
 	}
 
-	return channelID
+	return channelIDs
 }
 
 func playSound(s *discordgo.Session, guildID, channelID string, audioBuffer [][]byte) error {
@@ -0,0 +1,91 @@
+package coffeebeanbot
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+)
+
+// The CustomIDs of the buttons on the start message.
+const (
+	joinButtonID   = "pom_join"
+	cancelButtonID = "pom_cancel"
+	pauseButtonID  = "pom_pause"
+	resumeButtonID = "pom_resume"
+	extendButtonID = "pom_extend"
+)
+
+// controlButtons returns the buttons shown on the start message of a Pomodoro. Only one of Pause or Resume is shown,
+// depending on whether it's paused.
+func controlButtons(paused bool) []discordgo.MessageComponent {
+	pauseResume := discordgo.Button{Label: "Pause", Style: discordgo.SecondaryButton, CustomID: pauseButtonID}
+	if paused {
+		pauseResume = discordgo.Button{Label: "Resume", Style: discordgo.SuccessButton, CustomID: resumeButtonID}
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Join", Style: discordgo.PrimaryButton, CustomID: joinButtonID},
+			pauseResume,
+			discordgo.Button{Label: fmt.Sprintf("+%.0f min", defaultExtension.Minutes()), Style: discordgo.SecondaryButton, CustomID: extendButtonID},
+			discordgo.Button{Label: "Cancel", Style: discordgo.DangerButton, CustomID: cancelButtonID},
+		}},
+	}
+}
+
+// onComponent dispatches interactions with the buttons on our messages. Anyone can join, but the other buttons follow the
+// Guild's control policy, the same as their commands.
+func (bot *Bot) onComponent(s *discordgo.Session, i *discordgo.Interaction) {
+	switch i.MessageComponentData().CustomID {
+	case joinButtonID:
+		// Joining doesn't change the Pomodoro, so it skips the control policy
+		bot.onAppCmdJoin(s, i)
+	case cancelButtonID:
+		bot.cancelPom(s, i, "")
+	case pauseButtonID:
+		bot.onButtonPauseResume(s, i, bot.poms.Pause, true)
+	case resumeButtonID:
+		bot.onButtonPauseResume(s, i, bot.poms.Resume, false)
+	case extendButtonID:
+		bot.extendPom(s, i, defaultExtension)
+	}
+}
+
+// onButtonPauseResume performs the pause or resume, updating the live status message to show the other button and
+// announcing who pressed it.
+func (bot *Bot) onButtonPauseResume(s *discordgo.Session, i *discordgo.Interaction, op func(key pomodoro.Key) bool, paused bool) {
+	key := bot.pomKey(i)
+	if !bot.canControl(s, i, key) {
+		return
+	}
+
+	ok := op(key)
+
+	// Update the live status straight away, rather than waiting for its next update. If someone else got there first,
+	// this just makes sure the buttons are up to date.
+	status, exists := bot.poms.Status(key)
+	if !exists {
+		respond(s, i, "No Pomodoro running on this channel.", flagEphemeral)
+		return
+	}
+	s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    formatLiveStatus(status),
+			Components: controlButtons(status.Paused),
+		},
+	})
+	if !ok {
+		return
+	}
+
+	action := "resumed"
+	if paused {
+		action = "paused"
+	}
+	_, err := s.FollowupMessageCreate(i, false, &discordgo.WebhookParams{
+		Content: fmt.Sprintf("%s %s the Pomodoro.", interactionUser(i).Mention(), action),
+	})
+	LogIfError(bot.logger, err, "Error sending pause/resume followup", "channelID", i.ChannelID)
+}
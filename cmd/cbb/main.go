@@ -12,6 +12,7 @@ import (
 
 	"github.com/seanpfeifer/coffeebeanbot"
 	"github.com/seanpfeifer/coffeebeanbot/metrics"
+	"github.com/seanpfeifer/coffeebeanbot/store"
 )
 
 const (
@@ -65,8 +66,17 @@ func main() {
 		return
 	}
 
-	// Start bot
-	bot := coffeebeanbot.NewBot(*cfg, *secrets, logger, *recorder)
+	// Open persistent storage
+	dataStore, err := store.Open(cfg.Store, cfg.DataDir)
+	if coffeebeanbot.LogIfError(logger, err, "Error opening data store", "store", cfg.Store, "dataDir", cfg.DataDir) {
+		return
+	}
+	defer func() {
+		coffeebeanbot.LogIfError(logger, dataStore.Close(), "Error closing data store")
+	}()
+
+	// Start bot. This blocks until it has shut down, then the deferred calls flush the metrics and close the store.
+	bot := coffeebeanbot.NewBot(*cfg, *secrets, logger, *recorder, dataStore)
 	err = bot.Start()
 	coffeebeanbot.LogIfError(logger, err, "Error starting bot")
 }
@@ -9,23 +9,44 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/seanpfeifer/coffeebeanbot/metrics"
 	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
 )
 
 const (
-	discordBotPrefix = "Bot "
-	pomDuration      = time.Minute * 25
-	voiceWaitTime    = time.Millisecond * 250 // The amount of time to sleep before speaking & leaving the voice channel
-	startCmdName     = "pomstart"
-	cancelCmdName    = "pomcancel"
-	flagEphemeral    = 1 << 6 // The flag that specifies that a message is "ephemeral". ie, only visible to the caller
+	discordBotPrefix   = "Bot "
+	voiceWaitTime      = time.Millisecond * 250 // The amount of time to sleep before speaking & leaving the voice channel
+	startCmdName       = "pomstart"
+	cancelCmdName      = "pomcancel"
+	pauseCmdName       = "pompause"
+	resumeCmdName      = "pomresume"
+	statusCmdName      = "pomstatus"
+	configCmdName      = "pomconfig"
+	statsCmdName       = "pomstats"
+	leaderboardCmdName = "pomleaderboard"
+	joinCmdName        = "pomjoin"
+	leaveCmdName       = "pomleave"
+	extendCmdName      = "pomextend"
+	flagEphemeral      = 1 << 6 // The flag that specifies that a message is "ephemeral". ie, only visible to the caller
+	// How long shutdown waits for notifications and sounds to finish. This is within the 10s that `docker stop` allows.
+	shutdownTimeout = time.Second * 8
 )
 
+// pomStatus is the status of one of the bot's Pomodoros, which all have a NotifyInfo payload.
+type pomStatus = pomodoro.Status[NotifyInfo]
+
+// pomEvent is an event from one of the bot's Pomodoros.
+type pomEvent = pomodoro.Event[NotifyInfo]
+
+// pomSnapshot is the snapshot of one of the bot's Pomodoros.
+type pomSnapshot = pomodoro.Snapshot[NotifyInfo]
+
 // Bot contains the information needed to run the Discord bot
 type Bot struct {
 	Config  Config
@@ -33,22 +54,40 @@ type Bot struct {
 	discord *discordgo.Session
 	logger  *slog.Logger
 	metrics metrics.Recorder
-
-	poms               pomodoro.ChannelPomMap
-	workEndAudioBuffer [][]byte
+	store   store.Store
+
+	poms                 pomodoro.ChannelPomMap[NotifyInfo]
+	sessionsMutex        sync.Mutex                  // Ensures session snapshots are saved in the order they were taken
+	sessionsChanged      chan struct{}               // Signals that the sessions have changed since they were last saved
+	cmdsMutex            sync.RWMutex                // Read locked by each app cmd while it runs, and write locked to change acceptingCmds
+	acceptingCmds        bool                        // Whether app cmds are handled: once sessions are restored, until shutdown. Guarded by cmdsMutex.
+	inFlight             sync.WaitGroup              // Notifications, sounds and live status updates, which shutdown waits for
+	notifyMutex          sync.Mutex                  // Guards pendingNotifies
+	pendingNotifies      map[pomodoro.Key][]pomEvent // Events waiting to be notified for each Pomodoro that has a notifier running
+	liveStatusMutex      sync.Mutex                  // Orders the edits to live status messages
+	finishedLiveStatuses map[string]bool             // Live status messages that have been finished, so mustn't be edited again. Guarded by liveStatusMutex.
+	workEndAudioBuffer   [][]byte
+	milestoneAudioBuffer [][]byte
 }
 
 // NewBot is how you should create a new Bot in order to assure that all initialization has been completed.
-func NewBot(config Config, secrets Secrets, logger *slog.Logger, recorder metrics.Recorder) *Bot {
+func NewBot(config Config, secrets Secrets, logger *slog.Logger, recorder metrics.Recorder, dataStore store.Store) *Bot {
 	bot := &Bot{
 		Config:  config,
 		secrets: secrets,
 		logger:  logger,
 		metrics: recorder,
-		poms:    pomodoro.NewChannelPomMap(),
+		store:   dataStore,
+		poms:    pomodoro.NewChannelPomMap[NotifyInfo](),
+
+		sessionsChanged:      make(chan struct{}, 1),
+		pendingNotifies:      make(map[pomodoro.Key][]pomEvent),
+		finishedLiveStatuses: make(map[string]bool),
 	}
 
 	bot.loadSounds()
+	// Subscribed before anything can start a Pomodoro, so no events are missed
+	bot.subscribe()
 
 	return bot
 }
@@ -58,16 +97,37 @@ func (bot *Bot) loadSounds() {
 	if !LogIfError(bot.logger, err, "Error loading audio") {
 		bot.workEndAudioBuffer = audioBuffer
 	}
+
+	if bot.Config.MilestoneAudio != "" {
+		audioBuffer, err = LoadDiscordAudio(bot.Config.MilestoneAudio)
+		if !LogIfError(bot.logger, err, "Error loading milestone audio") {
+			bot.milestoneAudioBuffer = audioBuffer
+		}
+	}
 }
 
+// Where our app cmds can be used. Pomodoros can be run in DMs with the bot as personal timers, as well as on Guild
+// channels.
+var (
+	guildContexts      = []discordgo.InteractionContextType{discordgo.InteractionContextGuild}
+	guildAndDMContexts = []discordgo.InteractionContextType{discordgo.InteractionContextGuild, discordgo.InteractionContextBotDM}
+)
+
 func (bot *Bot) registerAppCmds() error {
 	// Intentionally not using the returned commands - we have no use for them, just the const names that we already have,
 	// which we'll use to determine which command a person has triggered.
-	_, err := bot.discord.ApplicationCommandBulkOverwrite(bot.secrets.AppID, "", []*discordgo.ApplicationCommand{
+	_, err := bot.discord.ApplicationCommandBulkOverwrite(bot.secrets.AppID, "", appCmds())
+	return err
+}
+
+// appCmds returns all of the bot's app cmds, as registered with Discord.
+func appCmds() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
 		{
 			Name:        startCmdName,
-			Description: "Starts a Pomodoro work cycle on the channel. You can optionally specify the task you are working on.",
+			Description: "Starts a Pomodoro cycle of work rounds and breaks. You can optionally specify your task.",
 			Type:        discordgo.ChatApplicationCommand,
+			Contexts:    &guildAndDMContexts,
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Name:         "task",
@@ -75,16 +135,78 @@ func (bot *Bot) registerAppCmds() error {
 					Description:  "The task you are working on",
 					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
 				},
+				minutesOption("minutes", "The length of each work round", minWorkMinutes, maxWorkMinutes),
+				minutesOption("break", "The length of each short break", minBreakMinutes, maxBreakMinutes),
+				minutesOption("long_break", "The length of the long break at the end of the set", minLongBreakMinutes, maxLongBreakMinutes),
+				{
+					Name:        "replace",
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Description: "Cancel any Pomodoro already running on the channel, and start this one instead",
+				},
 			},
 		},
 		{
 			Name:        cancelCmdName,
 			Description: "Cancels the current Pomodoro work cycle on the channel",
 			Type:        discordgo.ChatApplicationCommand,
+			Contexts:    &guildAndDMContexts,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "reason",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "Why you're cancelling, which is kept in the history",
+					MaxLength:   maxReasonLength,
+				},
+				pomUserOption("cancel"),
+			},
+		},
+		{
+			Name:        pauseCmdName,
+			Description: "Pauses the current Pomodoro on the channel, keeping the time remaining",
+			Type:        discordgo.ChatApplicationCommand,
+			Contexts:    &guildAndDMContexts,
+			Options:     []*discordgo.ApplicationCommandOption{pomUserOption("pause")},
 		},
-	})
-
-	return err
+		{
+			Name:        resumeCmdName,
+			Description: "Resumes the paused Pomodoro on the channel",
+			Type:        discordgo.ChatApplicationCommand,
+			Contexts:    &guildAndDMContexts,
+			Options:     []*discordgo.ApplicationCommandOption{pomUserOption("resume")},
+		},
+		{
+			Name:        extendCmdName,
+			Description: "Adds time to the current work round or break on the channel",
+			Type:        discordgo.ChatApplicationCommand,
+			Contexts:    &guildAndDMContexts,
+			Options: []*discordgo.ApplicationCommandOption{
+				minutesOption("minutes", fmt.Sprintf("The time to add, %.0f minutes if omitted", defaultExtension.Minutes()), minExtendMinutes, maxExtendMinutes),
+				pomUserOption("extend"),
+			},
+		},
+		{
+			Name:        joinCmdName,
+			Description: "Joins the current Pomodoro on the channel, so you're notified along with everyone else",
+			Type:        discordgo.ChatApplicationCommand,
+			Contexts:    &guildAndDMContexts,
+			Options:     []*discordgo.ApplicationCommandOption{pomUserOption("join")},
+		},
+		{
+			Name:        leaveCmdName,
+			Description: "Leaves the Pomodoro on the channel that you joined",
+			Type:        discordgo.ChatApplicationCommand,
+			Contexts:    &guildAndDMContexts,
+		},
+		{
+			Name:        statusCmdName,
+			Description: "Shows the time remaining and task of the current Pomodoro on the channel",
+			Type:        discordgo.ChatApplicationCommand,
+			Contexts:    &guildAndDMContexts,
+		},
+		configAppCmd(),
+		statsAppCmd(),
+		leaderboardAppCmd(),
+	}
 }
 
 // Start will start the bot, blocking until completion
@@ -115,14 +237,52 @@ func (bot *Bot) Start() error {
 	if err := bot.registerAppCmds(); err != nil {
 		return err
 	}
+	// App cmds are turned away until the sessions are restored, so a new Pomodoro can't be saved over them
+	bot.restoreSessions()
+	bot.cmdsMutex.Lock()
+	bot.acceptingCmds = true
+	bot.cmdsMutex.Unlock()
+
+	stopLiveStatuses := make(chan struct{})
+	bot.inFlight.Go(func() { bot.updateLiveStatuses(stopLiveStatuses) })
+	stopSaving := make(chan struct{})
+	var saving sync.WaitGroup
+	saving.Go(func() { bot.saveChangedSessions(stopSaving) })
 
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-sc
+	close(stopLiveStatuses)
+	// Saving must have stopped before the sessions are suspended, or it could save over them once the map is empty
+	close(stopSaving)
+	saving.Wait()
 
+	bot.shutdown()
 	return bot.discord.Close()
 }
 
+// shutdown gets the bot ready to exit. App cmds are turned away once those in progress are done, and the running
+// Pomodoros are suspended and saved so they can be restored when we start again. Anything still being sent to Discord
+// is then given until shutdownTimeout to finish, so it isn't cut off when the session closes.
+func (bot *Bot) shutdown() {
+	bot.cmdsMutex.Lock()
+	bot.acceptingCmds = false
+	bot.cmdsMutex.Unlock()
+
+	bot.suspendSessions()
+
+	done := make(chan struct{})
+	go func() {
+		bot.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		bot.logger.Warn("Timed out waiting for notifications and sounds to finish", "timeout", shutdownTimeout)
+	}
+}
+
 func (bot *Bot) onReady(s *discordgo.Session, event *discordgo.Ready) {
 	numGuilds := int64(len(s.State.Guilds))
 	bot.logger.Info("Bot connected and ready", "userName", event.User.Username+"#"+event.User.Discriminator, "numGuilds", numGuilds)
@@ -130,7 +290,18 @@ func (bot *Bot) onReady(s *discordgo.Session, event *discordgo.Ready) {
 }
 
 func (bot *Bot) onAppCmd(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Ignore anything that's not an app cmd
+	bot.cmdsMutex.RLock()
+	defer bot.cmdsMutex.RUnlock()
+	if !bot.acceptingCmds {
+		respond(s, i.Interaction, "The bot is restarting.  Please try again in a moment.", flagEphemeral)
+		return
+	}
+
+	if i.Type == discordgo.InteractionMessageComponent {
+		bot.onComponent(s, i.Interaction)
+		return
+	}
+	// Ignore anything else that's not an app cmd
 	if i.Type != discordgo.InteractionApplicationCommand {
 		return
 	}
@@ -141,49 +312,85 @@ func (bot *Bot) onAppCmd(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		bot.onAppCmdStart(s, i.Interaction)
 	case cancelCmdName:
 		bot.onAppCmdCancel(s, i.Interaction)
+	case pauseCmdName:
+		bot.onAppCmdPause(s, i.Interaction)
+	case resumeCmdName:
+		bot.onAppCmdResume(s, i.Interaction)
+	case extendCmdName:
+		bot.onAppCmdExtend(s, i.Interaction)
+	case joinCmdName:
+		bot.onAppCmdJoin(s, i.Interaction)
+	case leaveCmdName:
+		bot.onAppCmdLeave(s, i.Interaction)
+	case statusCmdName:
+		bot.onAppCmdStatus(s, i.Interaction)
+	case configCmdName:
+		bot.onAppCmdConfig(s, i.Interaction)
+	case statsCmdName:
+		bot.onAppCmdStats(s, i.Interaction)
+	case leaderboardCmdName:
+		bot.onAppCmdLeaderboard(s, i.Interaction)
 	}
 }
 
 func (bot *Bot) onAppCmdStart(s *discordgo.Session, i *discordgo.Interaction) {
 	data := i.ApplicationCommandData()
-	var task string
-	if len(data.Options) > 0 {
-		task = data.Options[0].StringValue()
+	task := optionString(data, "task")
+	// Members are only given in Guilds, so we use the interaction's user to support DMs too
+	user := interactionUser(i)
+	if user == nil {
+		return
 	}
 
-	channel, err := s.State.Channel(i.ChannelID)
-	if LogIfError(bot.logger, err, "Could not find channel", "channelID", i.ChannelID) {
-		// Could not find the channel, so simply log and exit
+	guildSettings := bot.guildSettings(i.GuildID)
+	settings, err := startSettings(data, guildSettings.Pomodoro)
+	if err != nil {
+		respond(s, i, err.Error(), flagEphemeral)
 		return
 	}
 
-	notif := pomodoro.NotifyInfo{
+	notif := NotifyInfo{
 		Title:     task,
-		UserID:    i.Member.User.ID,
-		GuildID:   channel.GuildID,
+		UserID:    user.ID,
+		GuildID:   i.GuildID,
 		ChannelID: i.ChannelID,
 	}
 
-	if bot.poms.CreateIfEmpty(pomDuration, bot.onPomEnded, notif) {
-		taskStr := "Started task  -  "
-		if len(notif.Title) > 0 {
-			taskStr = fmt.Sprintf("```md\n%s\n```", notif.Title)
+	// MaxPause isn't configurable, it just stops forgotten Pomodoros from running forever
+	settings.MaxPause = maxPauseTime
+	key := guildSettings.KeyMode.Key(i.ChannelID, user.ID)
+	if opt := data.GetOption("replace"); opt != nil && opt.BoolValue() {
+		if !bot.canControl(s, i, key) {
+			return
 		}
+		bot.poms.RemoveIfExists(key, pomodoro.OutcomeSuperseded, "")
+	}
 
-		msg := fmt.Sprintf("%s**%.1f minutes** remaining!", taskStr, pomDuration.Minutes())
+	if bot.poms.CreateIfEmpty(key, settings, nil, nil, nil, notif) {
+		// The start message becomes the live status message, which is kept up to date until the Pomodoro ends
+		status, _ := bot.poms.Status(key)
 		s.InteractionRespond(i, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: msg,
+				Content:    formatLiveStatus(status),
+				Components: controlButtons(false),
 			},
 		})
-		bot.metrics.RecordStartPom()
-		bot.metrics.RecordRunningPoms(int64(bot.poms.Count()))
+		// The interaction can only be edited for a short time, so we keep the message ID to edit it directly instead
+		if msg, err := s.InteractionResponse(i); !LogIfError(bot.logger, err, "Error getting start message", "channelID", i.ChannelID) {
+			bot.poms.Update(key, func(info *NotifyInfo) bool { return info.SetMessageID(msg.ID) })
+			// Updates aren't events, so the message ID is saved here
+			bot.saveSessions()
+		}
 	} else {
+		message := "A Pomodoro is already running on this channel."
+		if guildSettings.KeyMode != pomodoro.KeyPerChannel {
+			message = "You already have a Pomodoro running."
+		}
 		s.InteractionRespond(i, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "A Pomodoro is already running on this channel.",
+				Content: message + "  Use the `replace` option to replace it.",
 				Flags:   flagEphemeral,
 			},
 		})
@@ -191,52 +398,244 @@ func (bot *Bot) onAppCmdStart(s *discordgo.Session, i *discordgo.Interaction) {
 }
 
 func (bot *Bot) onAppCmdCancel(s *discordgo.Session, i *discordgo.Interaction) {
-	if exists := bot.poms.RemoveIfExists(i.ChannelID); !exists {
-		s.InteractionRespond(i, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "No Pomodoro running on this channel.",
-				Flags:   flagEphemeral,
-			},
-		})
+	bot.cancelPom(s, i, optionString(i.ApplicationCommandData(), "reason"))
+}
+
+// cancelPom cancels the Pomodoro the interaction is about with the given reason, which may be empty. A moderator
+// cancelling one they aren't taking part in counts as an admin cancel.
+func (bot *Bot) cancelPom(s *discordgo.Session, i *discordgo.Interaction, reason string) {
+	key := bot.pomKey(i)
+	if !bot.canControl(s, i, key) {
+		return
+	}
+
+	outcome := pomodoro.OutcomeCancelled
+	if status, exists := bot.poms.Status(key); exists {
+		if user := interactionUser(i); user != nil {
+			outcome = cancelOutcome(bot.guildSettings(status.Info.GuildID), status.Info, i.Member, user.ID)
+		}
+	}
+
+	if exists := bot.poms.RemoveIfExists(key, outcome, reason); !exists {
+		respond(s, i, "No Pomodoro running on this channel.", flagEphemeral)
 	} else {
-		s.InteractionRespond(i, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "Pomodoro cancelled!",
-			},
-		})
+		message := "Pomodoro cancelled!"
+		if reason != "" {
+			message = fmt.Sprintf("Pomodoro cancelled: %s", reason)
+		}
+		respond(s, i, message, 0)
 	}
 }
 
-// onPomEnded performs the notification
-func (bot *Bot) onPomEnded(notif pomodoro.NotifyInfo, completed bool) {
-	if completed {
-		message := "Work cycle complete.  Time for a short break!"
-		var toMention []string
+func (bot *Bot) onAppCmdPause(s *discordgo.Session, i *discordgo.Interaction) {
+	key := bot.pomKey(i)
+	if !bot.canControl(s, i, key) {
+		return
+	}
+	if paused := bot.poms.Pause(key); !paused {
+		respond(s, i, "No running Pomodoro to pause on this channel.", flagEphemeral)
+	} else {
+		respond(s, i, "Pomodoro paused.  Use `/"+resumeCmdName+"` to pick up where you left off.", 0)
+	}
+}
+
+func (bot *Bot) onAppCmdResume(s *discordgo.Session, i *discordgo.Interaction) {
+	key := bot.pomKey(i)
+	if !bot.canControl(s, i, key) {
+		return
+	}
+	if resumed := bot.poms.Resume(key); !resumed {
+		respond(s, i, "No paused Pomodoro to resume on this channel.", flagEphemeral)
+	} else {
+		respond(s, i, "Pomodoro resumed!", 0)
+	}
+}
+
+func (bot *Bot) onAppCmdExtend(s *discordgo.Session, i *discordgo.Interaction) {
+	extension, err := optionMinutes(i.ApplicationCommandData(), "minutes", minExtendMinutes, maxExtendMinutes, defaultExtension)
+	if err != nil {
+		respond(s, i, err.Error(), flagEphemeral)
+		return
+	}
+
+	bot.extendPom(s, i, extension)
+}
+
+// extendPom adds the extension to the current phase of the Pomodoro the interaction is about, announcing its new end
+// time. This handles both /pomextend and the extend button.
+func (bot *Bot) extendPom(s *discordgo.Session, i *discordgo.Interaction, extension time.Duration) {
+	key := bot.pomKey(i)
+	if !bot.canControl(s, i, key) {
+		return
+	}
+	if !bot.poms.Extend(key, extension) {
+		respond(s, i, "No Pomodoro running on this channel.", flagEphemeral)
+		return
+	}
+
+	msg := fmt.Sprintf("%s added %s to the Pomodoro.", interactionUser(i).Mention(), formatMinutes(extension))
+	if status, exists := bot.poms.Status(key); exists && !status.Paused {
+		msg += fmt.Sprintf("  The %s now ends <t:%d:t>.", status.Phase, status.Deadline.Unix())
+	}
+	respond(s, i, msg, 0)
+}
 
-		if len(notif.Title) > 0 {
-			message = fmt.Sprintf("```md\n%s\n```%s", notif.Title, message)
+func (bot *Bot) onAppCmdStatus(s *discordgo.Session, i *discordgo.Interaction) {
+	status, exists := bot.poms.Status(bot.pomKey(i))
+	if !exists {
+		respond(s, i, "No Pomodoro running on this channel.", flagEphemeral)
+		return
+	}
+
+	respond(s, i, formatStatus(status), flagEphemeral)
+}
+
+// pomKey returns the key of the Pomodoro the interaction is about. Buttons are about the Pomodoro of the message they're
+// on. Otherwise it's the Pomodoro the user means on the channel under the Guild's key mode, or the key they'd start one
+// with if there is none. App cmds with a user option mean that user's Pomodoro instead, if it's given.
+func (bot *Bot) pomKey(i *discordgo.Interaction) pomodoro.Key {
+	if i.Type == discordgo.InteractionMessageComponent && i.Message != nil {
+		// The zero key is never used, so if the message's Pomodoro has ended we'll find nothing rather than another one
+		key, _ := bot.poms.Find(func(status pomStatus) bool { return status.Info.MessageID == i.Message.ID })
+		return key
+	}
+
+	var userID string
+	if user := interactionUser(i); user != nil {
+		userID = user.ID
+	}
+	// The user option picks someone else's, eg so a moderator can reach a Pomodoro they aren't taking part in
+	if i.Type == discordgo.InteractionApplicationCommand {
+		if opt := i.ApplicationCommandData().GetOption("user"); opt != nil {
+			userID = opt.UserValue(nil).ID
 		}
+	}
+	key, _ := bot.lookupPom(i.GuildID, i.ChannelID, userID)
+	return key
+}
+
+// lookupPom returns the key of the Pomodoro the user means on the channel under the Guild's key mode. See
+// ChannelPomMap.Lookup.
+func (bot *Bot) lookupPom(guildID, channelID, userID string) (pomodoro.Key, bool) {
+	return bot.poms.Lookup(bot.guildSettings(guildID).KeyMode, channelID, userID, func(info NotifyInfo) bool {
+		return info.ChannelID == channelID && info.HasUser(userID)
+	})
+}
 
-		user, err := bot.discord.User(notif.UserID)
-		if err == nil {
-			toMention = append(toMention, user.Mention())
+// formatStatus describes the Pomodoro status for display in Discord.
+func formatStatus(status pomStatus) string {
+	var sb strings.Builder
+	sb.WriteString(withTitle(status.Info.Title, ""))
+	fmt.Fprintf(&sb, "**%s** remaining in the %s (work round %d of %d)", status.Remaining.Round(time.Second), status.Phase, status.Round, status.Rounds)
+	if status.Paused {
+		sb.WriteString("  -  **paused**")
+	} else {
+		fmt.Fprintf(&sb, ", ending <t:%d:t>", status.Deadline.Unix())
+	}
+	fmt.Fprintf(&sb, "\nStarted by <@%s> <t:%d:R>", status.Info.UserID, status.Started.Unix())
+	if len(status.Info.Participants) > 0 {
+		sb.WriteString(", joined by <@" + strings.Join(status.Info.Participants, ">, <@") + ">")
+	}
+
+	return sb.String()
+}
+
+// startSettings returns the Pomodoro settings to use for the /pomstart options given, using the defaults for any
+// that were omitted.
+func startSettings(data optionGetter, defaults pomodoro.Settings) (pomodoro.Settings, error) {
+	settings := defaults
+
+	var err error
+	if settings.Work, err = optionMinutes(data, "minutes", minWorkMinutes, maxWorkMinutes, settings.Work); err != nil {
+		return settings, err
+	}
+	if settings.ShortBreak, err = optionMinutes(data, "break", minBreakMinutes, maxBreakMinutes, settings.ShortBreak); err != nil {
+		return settings, err
+	}
+	if settings.LongBreak, err = optionMinutes(data, "long_break", minLongBreakMinutes, maxLongBreakMinutes, settings.LongBreak); err != nil {
+		return settings, err
+	}
+
+	return settings, nil
+}
+
+// respond replies to the interaction with a simple message. Use flagEphemeral to only show it to the caller.
+func respond(s *discordgo.Session, i *discordgo.Interaction, content string, flags discordgo.MessageFlags) {
+	s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   flags,
+		},
+	})
+}
+
+// announceTransition sends the notification for the transition between phases.
+func (bot *Bot) announceTransition(notif NotifyInfo, t pomodoro.Transition) {
+	var message string
+	switch {
+	case t.Ended == pomodoro.PhaseWork && t.Next == pomodoro.PhaseShortBreak:
+		message = fmt.Sprintf("Work round %d of %d complete.  Time for a short break!", t.Round, t.Rounds)
+	case t.Ended == pomodoro.PhaseWork && t.Next == pomodoro.PhaseLongBreak:
+		message = fmt.Sprintf("Work round %d of %d complete.  Time for a long break!", t.Round, t.Rounds)
+	case t.Ended == pomodoro.PhaseWork:
+		message = fmt.Sprintf("Work round %d of %d complete.  Keep going!", t.Round-1, t.Rounds)
+	default:
+		message = fmt.Sprintf("Break's over!  Starting work round %d of %d.", t.Round, t.Rounds)
+	}
+
+	if t.Delayed {
+		message += delayedNote
+	}
+
+	bot.notifyUsers(notif, message)
+}
+
+// notifyUsers sends the message to the Pomodoro's channel (or the Guild's announcement channel), mentioning everyone
+// taking part and playing the end sound in their voice channels, depending on the Guild's settings.
+func (bot *Bot) notifyUsers(notif NotifyInfo, message string) {
+	settings := bot.guildSettings(notif.GuildID)
+	var toMention []string
+	message = withTitle(notif.Title, message)
+
+	if settings.Mention {
+		for _, userID := range notif.Users() {
+			toMention = append(toMention, "<@"+userID+">")
 		}
+	}
+	if settings.MentionRoleID != "" {
+		toMention = append(toMention, "<@&"+settings.MentionRoleID+">")
+	}
+
+	if settings.Audio {
 		// Doing this in a goroutine so we don't wait until the audio has been played to send the text notification.
 		// This isn't required, but is my preference.
-		go bot.playEndSound(notif)
+		bot.inFlight.Go(func() { bot.playEndSound(notif) })
+	}
 
-		if len(toMention) > 0 {
-			mentions := strings.Join(toMention, " ")
-			message = fmt.Sprintf("%s\n%s", message, mentions)
-		}
+	if len(toMention) > 0 {
+		mentions := strings.Join(toMention, " ")
+		message = fmt.Sprintf("%s\n%s", message, mentions)
+	}
 
-		bot.discord.ChannelMessageSend(notif.ChannelID, message)
+	bot.discord.ChannelMessageSend(notifyChannel(settings, notif), message)
+}
+
+// notifyChannel returns the channel to notify about the Pomodoro on: the Guild's announcement channel if it has one,
+// otherwise the Pomodoro's own channel.
+func notifyChannel(settings store.GuildSettings, notif NotifyInfo) string {
+	if settings.AnnounceChannelID != "" {
+		return settings.AnnounceChannelID
 	}
-	// Otherwise this was cancelled, and the reply will already be sent by the app cmd
+	return notif.ChannelID
+}
 
-	bot.metrics.RecordRunningPoms(int64(bot.poms.Count()))
+// withTitle prefixes the message with the Pomodoro's task title as a block, if it has one.
+func withTitle(title, message string) string {
+	if len(title) > 0 {
+		return fmt.Sprintf("```md\n%s\n```%s", title, message)
+	}
+	return message
 }
 
 // onGuildCreate is called when a Guild adds the bot.
@@ -244,7 +643,14 @@ func (bot *Bot) onGuildCreate(s *discordgo.Session, event *discordgo.GuildCreate
 	bot.metrics.RecordConnectedServers(int64(len(s.State.Guilds)))
 }
 
-// onGuildDelete is called when a Guild removes the bot.
+// onGuildDelete is called when a Guild removes the bot, or becomes unavailable during an outage. The Guild's settings
+// are removed along with the bot, but kept through an outage.
 func (bot *Bot) onGuildDelete(s *discordgo.Session, event *discordgo.GuildDelete) {
 	bot.metrics.RecordConnectedServers(int64(len(s.State.Guilds)))
+	if event.Guild == nil || event.Unavailable {
+		return
+	}
+
+	err := bot.store.DeleteGuildSettings(event.ID)
+	LogIfError(bot.logger, err, "Error deleting guild settings", "guildID", event.ID)
 }
@@ -0,0 +1,103 @@
+package coffeebeanbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestFormatStatus(t *testing.T) {
+	started := time.Unix(1700000000, 0)
+	status := pomStatus{
+		Info:      NotifyInfo{Title: "Write tests", UserID: "123"},
+		Started:   started,
+		Phase:     pomodoro.PhaseShortBreak,
+		Round:     2,
+		Rounds:    4,
+		Deadline:  started.Add(time.Minute * 55),
+		Remaining: time.Minute*3 + time.Millisecond*400,
+	}
+
+	ExpectedActual(t, "```md\nWrite tests\n```**3m0s** remaining in the short break (work round 2 of 4), ending <t:1700003300:t>\nStarted by <@123> <t:1700000000:R>",
+		formatStatus(status), "running status")
+
+	status.Info.Title = ""
+	status.Paused = true
+	ExpectedActual(t, "**3m0s** remaining in the short break (work round 2 of 4)  -  **paused**\nStarted by <@123> <t:1700000000:R>",
+		formatStatus(status), "paused status without title")
+
+	status.Info.Participants = []string{"456", "789"}
+	ExpectedActual(t, "**3m0s** remaining in the short break (work round 2 of 4)  -  **paused**\nStarted by <@123> <t:1700000000:R>, joined by <@456>, <@789>",
+		formatStatus(status), "status with participants")
+}
+
+func TestVoiceChannelIDs(t *testing.T) {
+	voiceStates := []*discordgo.VoiceState{
+		{UserID: "Alice", ChannelID: "Lounge"},
+		{UserID: "Bob", ChannelID: "Library"},
+		{UserID: "Carol", ChannelID: "Lounge"},
+		{UserID: "Dave", ChannelID: "Cafe"},
+	}
+
+	ExpectedActual(t, []string{"Library", "Lounge"}, voiceChannelIDs(voiceStates, []string{"Bob", "Alice", "Carol", "Erin"}), "voice channels")
+	ExpectedActual(t, []string(nil), voiceChannelIDs(voiceStates, []string{"Erin"}), "no voice channels")
+}
+
+func TestStartSettings(t *testing.T) {
+	minutes := func(name string, value float64) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: value}
+	}
+
+	settings, err := startSettings(discordgo.ApplicationCommandInteractionData{}, pomodoro.DefaultSettings)
+	ExpectedActual(t, nil, err, "no options")
+	ExpectedActual(t, pomodoro.DefaultSettings, settings, "default settings")
+
+	settings, err = startSettings(discordgo.ApplicationCommandInteractionData{
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{minutes("minutes", 50), minutes("break", 10)},
+	}, pomodoro.DefaultSettings)
+	ExpectedActual(t, nil, err, "custom options")
+	ExpectedActual(t, time.Minute*50, settings.Work, "custom work")
+	ExpectedActual(t, time.Minute*10, settings.ShortBreak, "custom break")
+	ExpectedActual(t, pomodoro.DefaultSettings.LongBreak, settings.LongBreak, "default long break")
+
+	_, err = startSettings(discordgo.ApplicationCommandInteractionData{
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{minutes("long_break", maxLongBreakMinutes+1)},
+	}, pomodoro.DefaultSettings)
+	if err == nil {
+		t.Error("Expected an error for an out of bounds long break")
+	}
+}
+
+// Discord rejects the whole set of app cmds if any description is empty or longer than 100 characters
+func TestAppCmdDescriptions(t *testing.T) {
+	const maxDescription = 100
+	checkDescription := func(name, description string) {
+		if n := len([]rune(description)); n < 1 || n > maxDescription {
+			t.Errorf("Description of %q is %d characters, which must be 1-%d: %q", name, n, maxDescription, description)
+		}
+	}
+	var checkOptions func(path string, options []*discordgo.ApplicationCommandOption)
+	checkOptions = func(path string, options []*discordgo.ApplicationCommandOption) {
+		for _, option := range options {
+			checkDescription(path+" "+option.Name, option.Description)
+			checkOptions(path+" "+option.Name, option.Options)
+		}
+	}
+
+	for _, cmd := range appCmds() {
+		checkDescription(cmd.Name, cmd.Description)
+		checkOptions(cmd.Name, cmd.Options)
+	}
+}
+
+func TestNotifyChannel(t *testing.T) {
+	notif := NotifyInfo{ChannelID: "PomChannel"}
+	settings := store.DefaultGuildSettings()
+	ExpectedActual(t, "PomChannel", notifyChannel(settings, notif), "channel with no announcement channel")
+	settings.AnnounceChannelID = "AnnounceChannel"
+	ExpectedActual(t, "AnnounceChannel", notifyChannel(settings, notif), "channel with an announcement channel")
+}
@@ -2,9 +2,14 @@ package coffeebeanbot
 
 import "github.com/BurntSushi/toml"
 
+const defaultDataDir = "./data"
+
 // Config is the Bot's configuration data
 type Config struct {
-	WorkEndAudio string `toml:"workEndAudio"` // The DCA audio file that will be played when a Pomodoro ends. This is only played if the user is in voice chat in the Discord Server (Guild).
+	WorkEndAudio   string `toml:"workEndAudio"`   // The DCA audio file that will be played when a Pomodoro ends. This is only played if the user is in voice chat in the Discord Server (Guild).
+	MilestoneAudio string `toml:"milestoneAudio"` // The DCA audio file that will be played when a milestone is reached, eg 5 minutes left. Optional - no sound is played if empty.
+	DataDir        string `toml:"dataDir"`        // The directory that persistent data, such as Guild settings, is stored in.
+	Store          string `toml:"store"`          // The storage backend to keep persistent data with - either "file" (the default) or "bolt".
 }
 
 // Secrets is the Bot's per-user data, some of which is secret
@@ -17,7 +22,7 @@ type Secrets struct {
 // I generally prefer config files over environment variables, due to the ease of setting them up as secrets
 // in Kubernetes.
 func LoadConfigFile(path string) (*Config, error) {
-	var cfg Config
+	cfg := Config{DataDir: defaultDataDir}
 	_, err := toml.DecodeFile(path, &cfg)
 
 	return &cfg, err
@@ -10,4 +10,6 @@ func TestLoadConfig(t *testing.T) {
 	cfg, err := LoadConfigFile("./cfg.toml")
 	ExpectedActual(t, nil, err, "loading config file")
 	ExpectedActual(t, "./audio/airhorn.dca", cfg.WorkEndAudio, "work end audio")
+	ExpectedActual(t, "./data", cfg.DataDir, "data dir")
+	ExpectedActual(t, "file", cfg.Store, "store backend")
 }
@@ -0,0 +1,95 @@
+package coffeebeanbot
+
+import (
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+)
+
+// subscribe has the bot react to the events of its Pomodoros. Notifications, metrics, history and saved sessions each
+// have their own subscriber, so none of them hold up the others.
+func (bot *Bot) subscribe() {
+	bot.poms.Subscribe(bot.queueNotify)
+	bot.poms.Subscribe(bot.recordEventMetrics)
+	bot.poms.Subscribe(bot.recordEvent)
+	bot.poms.Subscribe(bot.saveOnEvent)
+}
+
+// queueNotify queues the event to be notified by its Pomodoro's notifier, starting one if it doesn't have one running.
+// Notifications wait on Discord, so each Pomodoro's are sent on a goroutine of its own rather than delaying the others,
+// but in the order they happened.
+func (bot *Bot) queueNotify(e pomEvent) {
+	bot.notifyMutex.Lock()
+	defer bot.notifyMutex.Unlock()
+
+	pending, running := bot.pendingNotifies[e.Key]
+	bot.pendingNotifies[e.Key] = append(pending, e)
+	if !running {
+		bot.inFlight.Go(func() { bot.notifyPending(e.Key) })
+	}
+}
+
+// notifyPending is the notifier for the Pomodoro with the key. It notifies its queued events in order, until there are
+// none left.
+func (bot *Bot) notifyPending(key pomodoro.Key) {
+	for {
+		bot.notifyMutex.Lock()
+		pending := bot.pendingNotifies[key]
+		if len(pending) == 0 {
+			delete(bot.pendingNotifies, key)
+			bot.notifyMutex.Unlock()
+			return
+		}
+		e := pending[0]
+		bot.pendingNotifies[key] = pending[1:]
+		bot.notifyMutex.Unlock()
+
+		bot.notifyEvent(e)
+	}
+}
+
+// notifyEvent lets everyone taking part in the Pomodoro know about the event, if it's one they're waiting on. Events
+// caused by an app cmd are already answered by its reply.
+func (bot *Bot) notifyEvent(e pomEvent) {
+	switch e.Type {
+	case pomodoro.EventMilestone:
+		bot.announceMilestone(e.Info, e.Milestone, e.Status)
+	case pomodoro.EventPhaseChanged:
+		// Like when restoring sessions, only the latest of the changes missed while the host slept is announced
+		if !overtaken(e) {
+			bot.announceTransition(e.Info, e.Transition)
+		}
+	case pomodoro.EventCompleted:
+		bot.finishLiveStatus(e.Info, e.Result.Outcome, e.Result.Reason)
+		message := "Pomodoro set complete.  Great work!"
+		if e.Result.Delayed {
+			message += delayedNote
+		}
+		bot.notifyUsers(e.Info, message)
+	case pomodoro.EventCancelled:
+		bot.finishLiveStatus(e.Info, e.Result.Outcome, e.Result.Reason)
+		if e.Result.Outcome == pomodoro.OutcomeTimeout {
+			bot.notifyUsers(e.Info, "Pomodoro cancelled after being paused for too long.")
+		}
+	}
+}
+
+// overtaken returns whether the phase change was late, and the phase it started was also over by the time it happened.
+// Another change will follow straight away, so there's no need to announce this one.
+func overtaken(e pomEvent) bool {
+	return e.Transition.Delayed && !e.Status.Deadline.After(e.Time)
+}
+
+// recordEventMetrics records Pomodoros starting and ending, along with how many are running. Restored Pomodoros were
+// already counted as started before the restart.
+func (bot *Bot) recordEventMetrics(e pomEvent) {
+	switch {
+	case e.Type == pomodoro.EventStarted:
+		if !e.Restored {
+			bot.metrics.RecordStartPom()
+		}
+	case e.Ended():
+		bot.metrics.RecordEndPom(e.Result.Outcome.String())
+	default:
+		return
+	}
+	bot.metrics.RecordRunningPoms(int64(e.Running))
+}
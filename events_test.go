@@ -0,0 +1,36 @@
+package coffeebeanbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+// Only the last of the phase changes missed while the host slept is announced
+func TestOvertaken(t *testing.T) {
+	clock := pomodoro.NewFakeClock(time.Date(2024, time.March, 14, 9, 0, 0, 0, time.UTC))
+	sched := pomodoro.NewScheduler(clock, 1)
+	defer sched.Stop()
+	poms := pomodoro.NewChannelPomMapWithScheduler[NotifyInfo](sched)
+	events := make(chan pomEvent, 16)
+	poms.Subscribe(func(e pomEvent) {
+		if e.Type == pomodoro.EventPhaseChanged || e.Ended() {
+			events <- e
+		}
+	})
+	settings := pomodoro.Settings{Work: time.Minute * 25, ShortBreak: time.Minute * 5, LongBreakInterval: 2}
+
+	// Waking after the break should have ended misses both the end of work and the break
+	poms.CreateIfEmpty(pomodoro.Key{ChannelID: "Nap"}, settings, nil, nil, nil, NotifyInfo{ChannelID: "Nap"})
+	clock.Advance(time.Minute * 32)
+	ExpectedActual(t, true, overtaken(<-events), "end of work overtaken by the end of the break")
+	ExpectedActual(t, false, overtaken(<-events), "end of the break")
+
+	// Waking after the set should have completed misses everything but the completion
+	clock.Advance(time.Hour)
+	completed := <-events
+	ExpectedActual(t, pomodoro.EventCompleted, completed.Type, "event after sleeping through the set")
+	ExpectedActual(t, true, completed.Result.Delayed, "delayed completion")
+}
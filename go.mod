@@ -7,6 +7,7 @@ require (
 	github.com/BurntSushi/toml v1.6.0
 	github.com/bwmarrin/discordgo v0.29.0
 	github.com/seanpfeifer/rigging v0.5.0
+	go.etcd.io/bbolt v1.4.3
 	go.opencensus.io v0.24.0
 )
 
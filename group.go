@@ -0,0 +1,46 @@
+package coffeebeanbot
+
+import "github.com/bwmarrin/discordgo"
+
+// onAppCmdJoin adds the caller to the Pomodoro on the channel. This handles both /pomjoin and the Join button. When each
+// person runs their own Pomodoro, /pomjoin's user option picks whose to join.
+func (bot *Bot) onAppCmdJoin(s *discordgo.Session, i *discordgo.Interaction) {
+	user := interactionUser(i)
+	if user == nil {
+		return
+	}
+
+	key := bot.pomKey(i)
+	if bot.poms.Update(key, func(info *NotifyInfo) bool { return info.Join(user.ID) }) {
+		respond(s, i, user.Mention()+" joined the Pomodoro!", 0)
+		bot.saveSessions()
+	} else if _, exists := bot.poms.Status(key); exists {
+		respond(s, i, "You're already part of this Pomodoro.", flagEphemeral)
+	} else {
+		respond(s, i, "No Pomodoro running on this channel.", flagEphemeral)
+	}
+}
+
+func (bot *Bot) onAppCmdLeave(s *discordgo.Session, i *discordgo.Interaction) {
+	user := interactionUser(i)
+	if user == nil {
+		return
+	}
+
+	key := bot.pomKey(i)
+	if bot.poms.Update(key, func(info *NotifyInfo) bool { return info.Leave(user.ID) }) {
+		respond(s, i, user.Mention()+" left the Pomodoro.", 0)
+		bot.saveSessions()
+		return
+	}
+
+	status, exists := bot.poms.Status(key)
+	switch {
+	case !exists:
+		respond(s, i, "No Pomodoro running on this channel.", flagEphemeral)
+	case status.Info.UserID == user.ID:
+		respond(s, i, "You started this Pomodoro, so you can't leave it.  Use `/"+cancelCmdName+"` to stop it instead.", flagEphemeral)
+	default:
+		respond(s, i, "You haven't joined this Pomodoro.", flagEphemeral)
+	}
+}
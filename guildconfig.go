@@ -0,0 +1,330 @@
+package coffeebeanbot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+)
+
+const (
+	minRounds = 1
+	maxRounds = 12
+
+	configShowCmdName  = "show"
+	configSetCmdName   = "set"
+	configResetCmdName = "reset"
+)
+
+// configAppCmd returns the /pomconfig command group. It is only usable in Guilds, and by default only by members
+// who can manage the server.
+func configAppCmd() *discordgo.ApplicationCommand {
+	var managePermission int64 = discordgo.PermissionManageGuild
+	dmPermission := false
+	minRoundsValue := float64(minRounds)
+
+	return &discordgo.ApplicationCommand{
+		Name:                     configCmdName,
+		Description:              "Configures the Pomodoro settings for this server",
+		Type:                     discordgo.ChatApplicationCommand,
+		DefaultMemberPermissions: &managePermission,
+		DMPermission:             &dmPermission,
+		Contexts:                 &guildContexts,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        configShowCmdName,
+				Description: "Shows the current settings",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        configSetCmdName,
+				Description: "Changes one or more settings",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					minutesOption("minutes", "The default length of each work round", minWorkMinutes, maxWorkMinutes),
+					minutesOption("break", "The default length of each short break", minBreakMinutes, maxBreakMinutes),
+					minutesOption("long_break", "The default length of the long break", minLongBreakMinutes, maxLongBreakMinutes),
+					{
+						Name:        "rounds",
+						Description: fmt.Sprintf("The number of work rounds before the long break (%d-%d)", minRounds, maxRounds),
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minRoundsValue,
+						MaxValue:    maxRounds,
+					},
+					{
+						Name:        "mention",
+						Description: "Whether to mention the user when a phase ends",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+					},
+					{
+						Name:        "role",
+						Description: "A role to mention when a phase ends",
+						Type:        discordgo.ApplicationCommandOptionRole,
+					},
+					{
+						Name:        "audio",
+						Description: "Whether to play a sound in voice chat when a phase ends",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+					},
+					{
+						Name:         "channel",
+						Description:  "The channel to send notifications to, instead of the channel the Pomodoro was started on",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Name:        "milestones",
+						Description: "Reminder points, eg `half,5,1` for halfway and 5 and 1 min left, or `none`",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
+					{
+						Name:        "milestone_post",
+						Description: "Whether to post a reminder when a milestone is reached",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+					},
+					{
+						Name:        "milestone_audio",
+						Description: "Whether to play a soft sound in voice chat when a milestone is reached",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+					},
+					{
+						Name:        "control",
+						Description: "Who can cancel, pause or extend a Pomodoro. Moderators always can.",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Those taking part", Value: store.ControlParticipants},
+							{Name: "Only the person who started it", Value: store.ControlStarter},
+							{Name: "Anyone", Value: store.ControlAnyone},
+						},
+					},
+					{
+						Name:        "timers",
+						Description: "Whether a channel shares one Pomodoro, or each person runs their own",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices:     keyModeChoices(),
+					},
+					{
+						Name:        "mod_role",
+						Description: "A role that can cancel or change any Pomodoro. Choosing one that's already set removes it.",
+						Type:        discordgo.ApplicationCommandOptionRole,
+					},
+				},
+			},
+			{
+				Name:        configResetCmdName,
+				Description: "Resets settings to their defaults",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "setting",
+						Description: "The setting to reset. Resets everything if omitted.",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "durations", Value: "durations"},
+							{Name: "role", Value: "role"},
+							{Name: "channel", Value: "channel"},
+							{Name: "milestones", Value: "milestones"},
+							{Name: "permissions", Value: "permissions"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// guildSettings returns the settings for the given Guild, or the defaults if it has none or they couldn't be loaded.
+// Pomodoros in DMs have no Guild, so always use the defaults.
+func (bot *Bot) guildSettings(guildID string) store.GuildSettings {
+	if guildID == "" {
+		return store.DefaultGuildSettings()
+	}
+
+	settings, exists, err := bot.store.GuildSettings(guildID)
+	if LogIfError(bot.logger, err, "Error loading guild settings", "guildID", guildID) || !exists {
+		return store.DefaultGuildSettings()
+	}
+	return settings
+}
+
+func (bot *Bot) onAppCmdConfig(s *discordgo.Session, i *discordgo.Interaction) {
+	// Discord hides the command from those without permission by default, but server admins can override that,
+	// so we check again here.
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+		respond(s, i, "You need the Manage Server permission to change Pomodoro settings.", flagEphemeral)
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		return
+	}
+	subCmd := data.Options[0]
+	settings := bot.guildSettings(i.GuildID)
+
+	switch subCmd.Name {
+	case configShowCmdName:
+		respond(s, i, formatGuildSettings(settings), flagEphemeral)
+		return
+	case configSetCmdName:
+		var err error
+		settings, err = applyGuildSettings(subCmd, settings)
+		if err != nil {
+			respond(s, i, err.Error(), flagEphemeral)
+			return
+		}
+	case configResetCmdName:
+		settings = resetGuildSettings(optionString(subCmd, "setting"), settings)
+	default:
+		return
+	}
+
+	err := bot.store.SetGuildSettings(i.GuildID, settings)
+	if LogIfError(bot.logger, err, "Error saving guild settings", "guildID", i.GuildID) {
+		respond(s, i, "Sorry, the settings could not be saved.  Please try again later.", flagEphemeral)
+		return
+	}
+
+	respond(s, i, "Settings updated.\n"+formatGuildSettings(settings), flagEphemeral)
+}
+
+// applyGuildSettings returns the settings with the changes from the options of the "set" subcommand applied.
+func applyGuildSettings(data optionGetter, settings store.GuildSettings) (store.GuildSettings, error) {
+	var err error
+	if settings.Pomodoro, err = startSettings(data, settings.Pomodoro); err != nil {
+		return settings, err
+	}
+
+	if opt := data.GetOption("rounds"); opt != nil {
+		rounds := int(opt.IntValue())
+		if rounds < minRounds || rounds > maxRounds {
+			return settings, fmt.Errorf("`rounds` must be between %d and %d", minRounds, maxRounds)
+		}
+		settings.Pomodoro.LongBreakInterval = rounds
+	}
+	if opt := data.GetOption("mention"); opt != nil {
+		settings.Mention = opt.BoolValue()
+	}
+	if opt := data.GetOption("role"); opt != nil {
+		settings.MentionRoleID = opt.RoleValue(nil, "").ID
+	}
+	if opt := data.GetOption("audio"); opt != nil {
+		settings.Audio = opt.BoolValue()
+	}
+	if opt := data.GetOption("channel"); opt != nil {
+		settings.AnnounceChannelID = opt.ChannelValue(nil).ID
+	}
+	if opt := data.GetOption("milestones"); opt != nil {
+		if settings.Pomodoro.Milestones, err = parseMilestones(opt.StringValue()); err != nil {
+			return settings, err
+		}
+	}
+	if opt := data.GetOption("milestone_post"); opt != nil {
+		settings.MilestonePost = opt.BoolValue()
+	}
+	if opt := data.GetOption("milestone_audio"); opt != nil {
+		settings.MilestoneAudio = opt.BoolValue()
+	}
+	if opt := data.GetOption("control"); opt != nil {
+		settings.ControlPolicy = opt.StringValue()
+	}
+	if opt := data.GetOption("timers"); opt != nil {
+		if settings.KeyMode, err = parseKeyMode(opt.StringValue()); err != nil {
+			return settings, err
+		}
+	}
+	if opt := data.GetOption("mod_role"); opt != nil {
+		if settings, err = toggleModRole(settings, opt.RoleValue(nil, "").ID); err != nil {
+			return settings, err
+		}
+	}
+
+	return settings, nil
+}
+
+// resetGuildSettings returns the settings with the named setting reset to its default, or all of them if name is "".
+func resetGuildSettings(name string, settings store.GuildSettings) store.GuildSettings {
+	defaults := store.DefaultGuildSettings()
+	switch name {
+	case "durations":
+		// Milestones are reset separately, even though they're kept with the durations
+		milestones := settings.Pomodoro.Milestones
+		settings.Pomodoro = defaults.Pomodoro
+		settings.Pomodoro.Milestones = milestones
+	case "milestones":
+		settings.Pomodoro.Milestones = defaults.Pomodoro.Milestones
+	case "role":
+		settings.MentionRoleID = defaults.MentionRoleID
+	case "channel":
+		settings.AnnounceChannelID = defaults.AnnounceChannelID
+	case "permissions":
+		settings.ControlPolicy = defaults.ControlPolicy
+		settings.ModRoleIDs = defaults.ModRoleIDs
+	default:
+		settings = defaults
+	}
+	return settings
+}
+
+// formatGuildSettings describes the settings for display in Discord.
+func formatGuildSettings(settings store.GuildSettings) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Work:** %.0f minutes\n", settings.Pomodoro.Work.Minutes())
+	fmt.Fprintf(&sb, "**Short break:** %.0f minutes\n", settings.Pomodoro.ShortBreak.Minutes())
+	fmt.Fprintf(&sb, "**Long break:** %.0f minutes, after %d work rounds\n", settings.Pomodoro.LongBreak.Minutes(), settings.Pomodoro.LongBreakInterval)
+	fmt.Fprintf(&sb, "**Mention users:** %s\n", onOff(settings.Mention))
+
+	role := "none"
+	if settings.MentionRoleID != "" {
+		role = "<@&" + settings.MentionRoleID + ">"
+	}
+	fmt.Fprintf(&sb, "**Mention role:** %s\n", role)
+	fmt.Fprintf(&sb, "**Audio:** %s\n", onOff(settings.Audio))
+
+	// With no Pomodoro to go on, an empty channel means each Pomodoro's own channel
+	channel := "the channel the Pomodoro was started on"
+	if channelID := notifyChannel(settings, NotifyInfo{}); channelID != "" {
+		channel = "<#" + channelID + ">"
+	}
+	fmt.Fprintf(&sb, "**Notifications sent to:** %s\n", channel)
+	fmt.Fprintf(&sb, "**Milestones:** %s (post %s, audio %s)\n", formatMilestones(settings.Pomodoro.Milestones), onOff(settings.MilestonePost), onOff(settings.MilestoneAudio))
+	fmt.Fprintf(&sb, "**Timers:** %s\n", keyModeDescriptions[settings.KeyMode])
+	fmt.Fprintf(&sb, "**Who can cancel or change Pomodoros:** %s", formatControlPolicy(settings))
+
+	return sb.String()
+}
+
+// keyModeDescriptions describes each KeyMode for display in Discord.
+var keyModeDescriptions = map[pomodoro.KeyMode]string{
+	pomodoro.KeyPerChannel:       "one per channel, shared by everyone on it",
+	pomodoro.KeyPerUserInChannel: "one per person on each channel",
+	pomodoro.KeyPerUser:          "one per person, on any channel",
+}
+
+// keyModeChoices returns the choices of the timers option of /pomconfig, one for each KeyMode.
+func keyModeChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(pomodoro.KeyModes))
+	for _, mode := range pomodoro.KeyModes {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: keyModeDescriptions[mode], Value: mode.String()})
+	}
+	return choices
+}
+
+// parseKeyMode returns the KeyMode with the given name, as given by its String method.
+func parseKeyMode(name string) (pomodoro.KeyMode, error) {
+	for _, mode := range pomodoro.KeyModes {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return pomodoro.KeyPerChannel, fmt.Errorf("`%s` isn't a timers mode", name)
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,58 @@
+package coffeebeanbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestApplyGuildSettings(t *testing.T) {
+	subCmd := discordgo.ApplicationCommandInteractionDataOption{
+		Name: configSetCmdName,
+		Type: discordgo.ApplicationCommandOptionSubCommand,
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "minutes", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(50)},
+			{Name: "rounds", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(3)},
+			{Name: "mention", Type: discordgo.ApplicationCommandOptionBoolean, Value: false},
+			{Name: "role", Type: discordgo.ApplicationCommandOptionRole, Value: "TheRole"},
+			{Name: "timers", Type: discordgo.ApplicationCommandOptionString, Value: "user_in_channel"},
+		},
+	}
+
+	settings, err := applyGuildSettings(subCmd, store.DefaultGuildSettings())
+	ExpectedActual(t, nil, err, "applying settings")
+	ExpectedActual(t, time.Minute*50, settings.Pomodoro.Work, "work duration")
+	ExpectedActual(t, 3, settings.Pomodoro.LongBreakInterval, "rounds")
+	ExpectedActual(t, false, settings.Mention, "mention")
+	ExpectedActual(t, "TheRole", settings.MentionRoleID, "mention role")
+	ExpectedActual(t, true, settings.Audio, "unchanged audio")
+	ExpectedActual(t, pomodoro.KeyPerUserInChannel, settings.KeyMode, "timers")
+
+	settings = resetGuildSettings("role", settings)
+	ExpectedActual(t, "", settings.MentionRoleID, "reset role")
+	ExpectedActual(t, time.Minute*50, settings.Pomodoro.Work, "work duration kept after resetting role")
+	ExpectedActual(t, store.DefaultGuildSettings(), resetGuildSettings("", settings), "reset everything")
+}
+
+func TestGuildSettingsInDM(t *testing.T) {
+	// DMs have no Guild, so they use the defaults without going to the store
+	bot := &Bot{}
+	ExpectedActual(t, store.DefaultGuildSettings(), bot.guildSettings(""), "settings for a DM")
+}
+
+func TestParseKeyMode(t *testing.T) {
+	for _, mode := range pomodoro.KeyModes {
+		parsed, err := parseKeyMode(mode.String())
+		ExpectedActual(t, nil, err, "parsing "+mode.String())
+		ExpectedActual(t, mode, parsed, "parsed mode")
+	}
+
+	_, err := parseKeyMode("everyone")
+	if err == nil {
+		t.Error("Expected an error parsing an unknown mode")
+	}
+}
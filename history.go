@@ -0,0 +1,76 @@
+package coffeebeanbot
+
+import (
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+)
+
+// recordEvent adds the Pomodoro's work rounds to the history as they end.
+func (bot *Bot) recordEvent(e pomEvent) {
+	switch {
+	case e.Type == pomodoro.EventPhaseChanged:
+		bot.recordTransition(e.Info, e.Transition)
+	case e.Ended():
+		bot.recordResult(e.Info, e.Result)
+	}
+}
+
+// recordTransition adds the work round to the history if the transition ended one. Breaks aren't recorded.
+func (bot *Bot) recordTransition(notif NotifyInfo, t pomodoro.Transition) {
+	if t.Ended != pomodoro.PhaseWork {
+		return
+	}
+
+	ended := time.Now()
+	if t.Delayed {
+		// We weren't running when it actually ended, so use when it was scheduled to end instead
+		ended = t.Started.Add(t.Duration)
+	}
+	bot.addHistory(notif, store.HistoryEntry{
+		Planned:   t.Duration - t.Extended,
+		Extended:  t.Extended,
+		Actual:    t.Duration,
+		Completed: true,
+		Outcome:   pomodoro.OutcomeCompleted.String(),
+		Started:   t.Started,
+		Ended:     ended,
+	})
+}
+
+// recordResult adds the final work round to the history. Pomodoros cancelled during a break, or before any work was
+// done, have no work left to record, but the cancel is still recorded along with its reason.
+func (bot *Bot) recordResult(notif NotifyInfo, result pomodoro.Result) {
+	ended := time.Now()
+	if result.Delayed {
+		// We weren't running when it actually ended, so use when it was scheduled to end instead
+		ended = result.PhaseStarted.Add(result.Planned)
+	}
+	entry := store.HistoryEntry{
+		Completed: result.Completed(),
+		Outcome:   result.Outcome.String(),
+		Reason:    result.Reason,
+		Started:   result.PhaseStarted,
+		Ended:     ended,
+	}
+	if result.Phase == pomodoro.PhaseWork {
+		entry.Planned = result.Planned - result.Extended
+		entry.Extended = result.Extended
+		entry.Actual = result.Elapsed
+	}
+	bot.addHistory(notif, entry)
+}
+
+// addHistory appends the work round to the history ledger for everyone taking part, logging any error. The entry's
+// user, Guild, channel and title are filled in from the NotifyInfo.
+func (bot *Bot) addHistory(notif NotifyInfo, entry store.HistoryEntry) {
+	entry.GuildID = notif.GuildID
+	entry.ChannelID = notif.ChannelID
+	entry.Title = notif.Title
+	for _, userID := range notif.Users() {
+		entry.UserID = userID
+		err := bot.store.AddHistory(entry)
+		LogIfError(bot.logger, err, "Error adding history", "userID", userID, "channelID", notif.ChannelID)
+	}
+}
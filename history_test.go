@@ -0,0 +1,37 @@
+package coffeebeanbot
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+// A cancel is recorded along with its reason, even when there's no work time to record
+func TestRecordResultCancelledInBreak(t *testing.T) {
+	dataStore, err := store.NewFileStore(t.TempDir())
+	ExpectedActual(t, nil, err, "opening store")
+	defer dataStore.Close()
+	bot := &Bot{logger: slog.New(slog.DiscardHandler), store: dataStore}
+
+	bot.recordResult(NotifyInfo{UserID: "TheUser", ChannelID: "TheChannel"}, pomodoro.Result{
+		Outcome:      pomodoro.OutcomeCancelled,
+		Reason:       "Lunch",
+		Phase:        pomodoro.PhaseShortBreak,
+		Round:        1,
+		PhaseStarted: time.Now().Add(-time.Minute),
+		Planned:      time.Minute * 5,
+		Elapsed:      time.Minute,
+	})
+
+	entries, err := dataStore.History(store.HistoryQuery{UserID: "TheUser"})
+	ExpectedActual(t, nil, err, "getting history")
+	ExpectedActual(t, 1, len(entries), "number of entries")
+	ExpectedActual(t, false, entries[0].Completed, "entry completed")
+	ExpectedActual(t, "Lunch", entries[0].Reason, "entry reason")
+	ExpectedActual(t, time.Duration(0), entries[0].Actual, "entry work time")
+	ExpectedActual(t, time.Duration(0), entries[0].Planned, "entry planned work time")
+}
@@ -0,0 +1,223 @@
+package coffeebeanbot
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+)
+
+const (
+	leaderboardSize = 10 // The number of members shown on the leaderboard
+
+	periodDay   = "day"
+	periodWeek  = "week"
+	periodMonth = "month"
+	periodAll   = "all"
+
+	metricPomodoros = "pomodoros"
+	metricMinutes   = "minutes"
+	metricStreak    = "streak"
+)
+
+// leaderboardRow is a single member's ranking on the leaderboard.
+type leaderboardRow struct {
+	UserID string
+	Value  int
+}
+
+// leaderboardAppCmd returns the /pomleaderboard command. It is only usable in Guilds.
+func leaderboardAppCmd() *discordgo.ApplicationCommand {
+	dmPermission := false
+
+	return &discordgo.ApplicationCommand{
+		Name:         leaderboardCmdName,
+		Description:  "Ranks the members of this server by their Pomodoros",
+		Type:         discordgo.ChatApplicationCommand,
+		DMPermission: &dmPermission,
+		Contexts:     &guildContexts,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "period",
+				Description: "The period to rank over. Defaults to this week.",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "today", Value: periodDay},
+					{Name: "this week", Value: periodWeek},
+					{Name: "this month", Value: periodMonth},
+					{Name: "all time", Value: periodAll},
+				},
+			},
+			{
+				Name:        "metric",
+				Description: "What to rank by. Defaults to focus minutes.",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "completed pomodoros", Value: metricPomodoros},
+					{Name: "focus minutes", Value: metricMinutes},
+					{Name: "current daily streak", Value: metricStreak},
+				},
+			},
+			{
+				Name:        "hide_me",
+				Description: "Whether to hide yourself from all leaderboards. Remembered for next time.",
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+			},
+		},
+	}
+}
+
+func (bot *Bot) onAppCmdLeaderboard(s *discordgo.Session, i *discordgo.Interaction) {
+	user := interactionUser(i)
+	if user == nil || i.GuildID == "" {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if opt := data.GetOption("hide_me"); opt != nil {
+		bot.setLeaderboardHide(user.ID, opt.BoolValue())
+	}
+
+	period := cmp.Or(optionString(data, "period"), periodWeek)
+	metric := cmp.Or(optionString(data, "metric"), metricMinutes)
+	// Periods start according to the caller's time zone, since they're the one looking
+	loc, _ := bot.userLocation(user.ID, "")
+	now := time.Now()
+
+	// Streaks need the full history, regardless of the period
+	query := store.HistoryQuery{GuildID: i.GuildID}
+	if metric != metricStreak {
+		query.From = periodStart(period, now, loc)
+	}
+	entries, err := bot.store.History(query)
+	if LogIfError(bot.logger, err, "Error loading history", "guildID", i.GuildID) {
+		respond(s, i, "Sorry, the leaderboard couldn't be loaded right now.", flagEphemeral)
+		return
+	}
+
+	rows := rankLeaderboard(entries, metric, now, loc, bot.leaderboardHidden)
+	s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{leaderboardEmbed(rows, period, metric)},
+		},
+	})
+}
+
+// setLeaderboardHide saves whether the user is hidden from leaderboards to their preferences.
+func (bot *Bot) setLeaderboardHide(userID string, hide bool) {
+	prefs, _, err := bot.store.UserPrefs(userID)
+	if LogIfError(bot.logger, err, "Error loading user prefs", "userID", userID) {
+		return
+	}
+	prefs.LeaderboardHide = hide
+	LogIfError(bot.logger, bot.store.SetUserPrefs(userID, prefs), "Error saving user prefs", "userID", userID)
+}
+
+// leaderboardHidden returns whether the user has opted out of leaderboards.
+func (bot *Bot) leaderboardHidden(userID string) bool {
+	prefs, _, err := bot.store.UserPrefs(userID)
+	LogIfError(bot.logger, err, "Error loading user prefs", "userID", userID)
+	return prefs.LeaderboardHide
+}
+
+// periodStart returns when the named period started, in loc. The zero time is returned for all time.
+func periodStart(period string, now time.Time, loc *time.Location) time.Time {
+	switch period {
+	case periodDay:
+		return startOfDay(now, loc)
+	case periodWeek:
+		return startOfWeek(now, loc)
+	case periodMonth:
+		year, month, _ := now.In(loc).Date()
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	}
+	return time.Time{}
+}
+
+// rankLeaderboard totals the metric for each user in the entries, returning the top leaderboardSize in descending order.
+// Users that are hidden, or have nothing to show, are left out.
+func rankLeaderboard(entries []store.HistoryEntry, metric string, now time.Time, loc *time.Location, hidden func(userID string) bool) []leaderboardRow {
+	byUser := make(map[string][]store.HistoryEntry)
+	for _, entry := range entries {
+		byUser[entry.UserID] = append(byUser[entry.UserID], entry)
+	}
+
+	var rows []leaderboardRow
+	for userID, userEntries := range byUser {
+		row := leaderboardRow{UserID: userID}
+		switch metric {
+		case metricPomodoros:
+			for _, entry := range userEntries {
+				if entry.Completed {
+					row.Value++
+				}
+			}
+		case metricMinutes:
+			var focus time.Duration
+			for _, entry := range userEntries {
+				focus += entry.Actual
+			}
+			row.Value = int(focus.Minutes())
+		case metricStreak:
+			row.Value = dailyStreak(userEntries, now, loc)
+		}
+
+		if row.Value > 0 && !hidden(userID) {
+			rows = append(rows, row)
+		}
+	}
+
+	// Ties are broken by user ID so the order is stable between calls
+	slices.SortFunc(rows, func(a, b leaderboardRow) int {
+		return cmp.Or(cmp.Compare(b.Value, a.Value), strings.Compare(a.UserID, b.UserID))
+	})
+	return rows[:min(len(rows), leaderboardSize)]
+}
+
+// leaderboardEmbed renders the leaderboard for display in Discord.
+func leaderboardEmbed(rows []leaderboardRow, period, metric string) *discordgo.MessageEmbed {
+	var unit, periodName string
+	switch metric {
+	case metricPomodoros:
+		unit = "pomodoros"
+	case metricMinutes:
+		unit = "focus minutes"
+	case metricStreak:
+		unit = "days"
+	}
+	switch period {
+	case periodDay:
+		periodName = "today"
+	case periodWeek:
+		periodName = "this week"
+	case periodMonth:
+		periodName = "this month"
+	case periodAll:
+		periodName = "all time"
+	}
+
+	title := fmt.Sprintf("Leaderboard - %s, %s", unit, periodName)
+	if metric == metricStreak {
+		title = "Leaderboard - current daily streak"
+	}
+
+	var sb strings.Builder
+	for rank, row := range rows {
+		fmt.Fprintf(&sb, "%d. <@%s> - **%d** %s\n", rank+1, row.UserID, row.Value, unit)
+	}
+	if len(rows) == 0 {
+		sb.WriteString("Nobody yet - use `/" + startCmdName + "` to get on the board!")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: sb.String(),
+		Color:       statsEmbedColor,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Use the hide_me option to leave the leaderboard"},
+	}
+}
@@ -0,0 +1,43 @@
+package coffeebeanbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/store"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestRankLeaderboard(t *testing.T) {
+	now := time.Date(2024, time.March, 13, 9, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	entries := []store.HistoryEntry{
+		{UserID: "Alice", Completed: true, Actual: 25 * time.Minute, Ended: now},
+		{UserID: "Alice", Completed: false, Actual: 40 * time.Minute, Ended: now},
+		{UserID: "Bob", Completed: true, Actual: 25 * time.Minute, Ended: yesterday},
+		{UserID: "Bob", Completed: true, Actual: 25 * time.Minute, Ended: now},
+		{UserID: "Carol", Completed: true, Actual: 50 * time.Minute, Ended: now},
+		{UserID: "Dave", Completed: true, Actual: 90 * time.Minute, Ended: now},
+	}
+	hidden := func(userID string) bool { return userID == "Dave" }
+
+	ExpectedActual(t,
+		[]leaderboardRow{{"Bob", 2}, {"Alice", 1}, {"Carol", 1}},
+		rankLeaderboard(entries, metricPomodoros, now, time.UTC, hidden), "ranking by pomodoros")
+	ExpectedActual(t,
+		[]leaderboardRow{{"Alice", 65}, {"Bob", 50}, {"Carol", 50}},
+		rankLeaderboard(entries, metricMinutes, now, time.UTC, hidden), "ranking by minutes")
+	ExpectedActual(t,
+		[]leaderboardRow{{"Bob", 2}, {"Alice", 1}, {"Carol", 1}},
+		rankLeaderboard(entries, metricStreak, now, time.UTC, hidden), "ranking by streak")
+}
+
+func TestPeriodStart(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2024, time.March, 13, 9, 30, 0, 0, time.UTC)
+
+	ExpectedActual(t, time.Date(2024, time.March, 13, 0, 0, 0, 0, time.UTC), periodStart(periodDay, now, time.UTC), "start of day")
+	ExpectedActual(t, time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC), periodStart(periodWeek, now, time.UTC), "start of week")
+	ExpectedActual(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), periodStart(periodMonth, now, time.UTC), "start of month")
+	ExpectedActual(t, true, periodStart(periodAll, now, time.UTC).IsZero(), "start of all time")
+}
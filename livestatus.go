@@ -0,0 +1,157 @@
+package coffeebeanbot
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+)
+
+const (
+	liveStatusInterval = time.Minute // How often the live status messages are updated
+	progressBarWidth   = 20          // The number of characters in the progress bar
+)
+
+// updateLiveStatuses keeps the live status message of each running Pomodoro up to date until stop is closed.
+func (bot *Bot) updateLiveStatuses(stop <-chan struct{}) {
+	ticker := time.NewTicker(liveStatusInterval)
+	defer ticker.Stop()
+
+	// The content last sent to each message, by message ID, so we only edit those that have changed
+	sent := make(map[string]string)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			bot.refreshLiveStatuses(sent)
+		}
+	}
+}
+
+// refreshLiveStatuses edits each live status message whose content has changed since it was last sent. Edits are made
+// one at a time, and discordgo waits out any rate limits we hit, so having many Pomodoros running slows the updates
+// down rather than failing them.
+func (bot *Bot) refreshLiveStatuses(sent map[string]string) {
+	running := make(map[string]bool)
+	for _, status := range bot.poms.Statuses() {
+		messageID := status.Info.MessageID
+		if messageID == "" {
+			continue
+		}
+		running[messageID] = true
+
+		content := formatLiveStatus(status)
+		if sent[messageID] == content {
+			continue
+		}
+		bot.liveStatusMutex.Lock()
+		// The Pomodoro may have ended since we got its status, in which case its final status must be left alone
+		if !bot.finishedLiveStatuses[messageID] {
+			bot.editLiveStatus(status.Info, content, controlButtons(status.Paused))
+		}
+		bot.liveStatusMutex.Unlock()
+		sent[messageID] = content
+	}
+
+	// Forget the messages of Pomodoros that have ended. Those that ended before we got the statuses can't be edited by
+	// later refreshes either, so needn't be kept as finished.
+	for messageID := range sent {
+		if !running[messageID] {
+			delete(sent, messageID)
+		}
+	}
+	bot.liveStatusMutex.Lock()
+	for messageID := range bot.finishedLiveStatuses {
+		if !running[messageID] {
+			delete(bot.finishedLiveStatuses, messageID)
+		}
+	}
+	bot.liveStatusMutex.Unlock()
+}
+
+// finishLiveStatus switches the live status message of an ended Pomodoro to its final state, removing the buttons.
+func (bot *Bot) finishLiveStatus(notif NotifyInfo, outcome pomodoro.Outcome, reason string) {
+	if notif.MessageID == "" {
+		return
+	}
+
+	bot.liveStatusMutex.Lock()
+	defer bot.liveStatusMutex.Unlock()
+	bot.finishedLiveStatuses[notif.MessageID] = true
+	bot.editLiveStatus(notif, formatLiveEnded(notif, outcome, reason), []discordgo.MessageComponent{})
+}
+
+// editLiveStatus replaces the content and buttons of the Pomodoro's live status message.
+func (bot *Bot) editLiveStatus(notif NotifyInfo, content string, components []discordgo.MessageComponent) {
+	_, err := bot.discord.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         notif.MessageID,
+		Channel:    notif.ChannelID,
+		Content:    &content,
+		Components: &components,
+	})
+	LogIfError(bot.logger, err, "Error updating live status", "channelID", notif.ChannelID, "messageID", notif.MessageID)
+}
+
+// formatLiveStatus describes the Pomodoro status for its live status message. Unlike formatStatus, the time remaining is
+// only shown to the minute, so the message only needs editing once a minute.
+func formatLiveStatus(status pomStatus) string {
+	var sb strings.Builder
+	sb.WriteString(withTitle(status.Info.Title, ""))
+	fmt.Fprintf(&sb, "Currently on the **%s** (work round %d of %d)\n", status.Phase, status.Round, status.Rounds)
+
+	elapsed := 1.0
+	if status.Duration > 0 {
+		elapsed = 1 - float64(status.Remaining)/float64(status.Duration)
+	}
+	fmt.Fprintf(&sb, "%s  **%d min** left", progressBar(elapsed), int(math.Ceil(status.Remaining.Minutes())))
+	if status.Paused {
+		sb.WriteString("  -  **paused**")
+	} else {
+		fmt.Fprintf(&sb, ", ending <t:%d:t>", status.Deadline.Unix())
+	}
+
+	fmt.Fprintf(&sb, "\nStarted by <@%s>", status.Info.UserID)
+	if len(status.Info.Participants) > 0 {
+		sb.WriteString(", joined by <@" + strings.Join(status.Info.Participants, ">, <@") + ">")
+	}
+
+	return sb.String()
+}
+
+// formatLiveEnded describes the final state of an ended Pomodoro for its live status message, including the reason it
+// was cancelled if one was given.
+func formatLiveEnded(notif NotifyInfo, outcome pomodoro.Outcome, reason string) string {
+	var sb strings.Builder
+	sb.WriteString(withTitle(notif.Title, ""))
+	switch outcome {
+	case pomodoro.OutcomeCompleted:
+		sb.WriteString(progressBar(1) + "  **Done!**")
+	case pomodoro.OutcomeAdminCancelled:
+		sb.WriteString("**Cancelled by a moderator**")
+	case pomodoro.OutcomeSuperseded:
+		sb.WriteString("**Replaced by a new Pomodoro**")
+	case pomodoro.OutcomeTimeout:
+		sb.WriteString("**Cancelled after being paused for too long**")
+	case pomodoro.OutcomeRestart:
+		sb.WriteString("**Stopped by a restart**")
+	default:
+		sb.WriteString("**Cancelled**")
+	}
+	if reason != "" {
+		sb.WriteString(": " + reason)
+	}
+
+	return sb.String()
+}
+
+// progressBar renders the fraction, which is clamped to [0, 1], as a text progress bar.
+func progressBar(fraction float64) string {
+	fraction = min(max(fraction, 0), 1)
+	filled := int(math.Round(fraction * progressBarWidth))
+
+	return fmt.Sprintf("`%s%s` %d%%", strings.Repeat("█", filled), strings.Repeat("░", progressBarWidth-filled), int(math.Round(fraction*100)))
+}
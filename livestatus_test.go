@@ -0,0 +1,49 @@
+package coffeebeanbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestFormatLiveStatus(t *testing.T) {
+	started := time.Unix(1700000000, 0)
+	status := pomStatus{
+		Info:      NotifyInfo{Title: "Write tests", UserID: "123"},
+		Started:   started,
+		Phase:     pomodoro.PhaseWork,
+		Round:     2,
+		Rounds:    4,
+		Deadline:  started.Add(time.Minute * 45),
+		Remaining: time.Minute*14 + time.Second*30,
+		Duration:  time.Minute * 25,
+	}
+
+	ExpectedActual(t, "```md\nWrite tests\n```Currently on the **work** (work round 2 of 4)\n`████████░░░░░░░░░░░░` 42%  **15 min** left, ending <t:1700002700:t>\nStarted by <@123>",
+		formatLiveStatus(status), "running live status")
+
+	status.Info.Title = ""
+	status.Info.Participants = []string{"456"}
+	status.Paused = true
+	ExpectedActual(t, "Currently on the **work** (work round 2 of 4)\n`████████░░░░░░░░░░░░` 42%  **15 min** left  -  **paused**\nStarted by <@123>, joined by <@456>",
+		formatLiveStatus(status), "paused live status")
+}
+
+func TestFormatLiveEnded(t *testing.T) {
+	notif := NotifyInfo{Title: "Write tests"}
+	ExpectedActual(t, "```md\nWrite tests\n````████████████████████` 100%  **Done!**", formatLiveEnded(notif, pomodoro.OutcomeCompleted, ""), "completed live status")
+
+	notif.Title = ""
+	ExpectedActual(t, "**Cancelled**", formatLiveEnded(notif, pomodoro.OutcomeCancelled, ""), "cancelled live status")
+	ExpectedActual(t, "**Cancelled by a moderator**: Quiet hours", formatLiveEnded(notif, pomodoro.OutcomeAdminCancelled, "Quiet hours"), "admin cancelled live status")
+	ExpectedActual(t, "**Replaced by a new Pomodoro**", formatLiveEnded(notif, pomodoro.OutcomeSuperseded, ""), "superseded live status")
+}
+
+func TestProgressBar(t *testing.T) {
+	ExpectedActual(t, "`░░░░░░░░░░░░░░░░░░░░` 0%", progressBar(0), "empty progress")
+	ExpectedActual(t, "`██████████░░░░░░░░░░` 50%", progressBar(0.5), "half progress")
+	ExpectedActual(t, "`████████████████████` 100%", progressBar(1), "full progress")
+	ExpectedActual(t, "`████████████████████` 100%", progressBar(1.5), "clamped progress")
+}
@@ -8,12 +8,17 @@ import (
 
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
 )
 
+// outcomeKey tags the ended Pomodoros with how they ended, eg "completed" or "cancelled".
+var outcomeKey = tag.MustNewKey("outcome")
+
 // Recorder is our backend-independent metrics recorder.
 // This should be created with NewRecorder().
 type Recorder struct {
 	startPomCount   *stats.Int64Measure
+	endPomCount     *stats.Int64Measure
 	runningPomCount *stats.Int64Measure
 	serverCount     *stats.Int64Measure
 }
@@ -22,6 +27,7 @@ type Recorder struct {
 func NewRecorder() (*Recorder, error) {
 	recorder := &Recorder{
 		startPomCount:   stats.Int64("pomodoros_started", "Count of Pomodoros started", stats.UnitDimensionless),
+		endPomCount:     stats.Int64("pomodoros_ended", "Count of Pomodoros ended", stats.UnitDimensionless),
 		runningPomCount: stats.Int64("pomodoros_running", "Current number of Pomodoros running", stats.UnitDimensionless),
 		serverCount:     stats.Int64("connected_servers", "Current number of connected servers", stats.UnitDimensionless),
 	}
@@ -33,6 +39,14 @@ func NewRecorder() (*Recorder, error) {
 		Aggregation: view.Count(),
 	}
 
+	endView := &view.View{
+		Name:        "pomodoros_ended_count",
+		Measure:     recorder.endPomCount,
+		Description: "The number of Pomodoros ended, by outcome",
+		TagKeys:     []tag.Key{outcomeKey},
+		Aggregation: view.Count(),
+	}
+
 	runningView := &view.View{
 		Name:        "pomodoros_running_value",
 		Measure:     recorder.runningPomCount,
@@ -47,7 +61,7 @@ func NewRecorder() (*Recorder, error) {
 		Aggregation: view.LastValue(),
 	}
 
-	return recorder, view.Register(startView, runningView, serverView)
+	return recorder, view.Register(startView, endView, runningView, serverView)
 }
 
 // RecordStartPom records the start of a pomodoro.
@@ -55,6 +69,11 @@ func (r *Recorder) RecordStartPom() {
 	stats.Record(context.Background(), r.startPomCount.M(1))
 }
 
+// RecordEndPom records the end of a pomodoro, and how it ended.
+func (r *Recorder) RecordEndPom(outcome string) {
+	stats.RecordWithTags(context.Background(), []tag.Mutator{tag.Upsert(outcomeKey, outcome)}, r.endPomCount.M(1))
+}
+
 // RecordRunningPoms records the number of currently running pomodoros.
 func (r *Recorder) RecordRunningPoms(count int64) {
 	stats.Record(context.Background(), r.runningPomCount.M(count))
@@ -0,0 +1,95 @@
+package coffeebeanbot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+)
+
+const (
+	maxMilestones     = 5      // The most milestones a Guild can set
+	milestoneHalfway  = "half" // The name of the halfway milestone in the /pomconfig option
+	milestonesOffName = "none" // Turns milestones off in the /pomconfig option
+)
+
+// announceMilestone posts a short notice and/or plays the milestone sound when a milestone is reached, depending on the
+// Guild's settings. Unlike phase changes, nobody is mentioned, so the notice stays a gentle reminder.
+func (bot *Bot) announceMilestone(notif NotifyInfo, m pomodoro.Milestone, status pomStatus) {
+	settings := bot.guildSettings(notif.GuildID)
+
+	if settings.MilestoneAudio {
+		bot.inFlight.Go(func() { bot.playSoundForUsers(notif, bot.milestoneAudioBuffer) })
+	}
+	if !settings.MilestonePost {
+		return
+	}
+
+	channelID := notifyChannel(settings, notif)
+	message := fmt.Sprintf("**%s** left in work round %d of %d.", formatMinutes(status.Remaining), status.Round, status.Rounds)
+	if m.Remaining == 0 && m.Fraction == 0.5 {
+		message = fmt.Sprintf("Halfway through work round %d of %d.", status.Round, status.Rounds)
+	}
+	_, err := bot.discord.ChannelMessageSend(channelID, message)
+	LogIfError(bot.logger, err, "Error sending milestone", "channelID", channelID)
+}
+
+// parseMilestones parses the milestones option of /pomconfig, a comma-separated list of "half" and numbers of minutes
+// left, eg "half,5,1". "none" returns no milestones.
+func parseMilestones(value string) ([]pomodoro.Milestone, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == milestonesOffName {
+		return nil, nil
+	}
+
+	var milestones []pomodoro.Milestone
+	for part := range strings.SplitSeq(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == milestoneHalfway {
+			milestones = append(milestones, pomodoro.Milestone{Fraction: 0.5})
+			continue
+		}
+
+		minutes, err := strconv.Atoi(part)
+		if err != nil || minutes < minWorkMinutes || minutes > maxWorkMinutes {
+			return nil, fmt.Errorf("`%s` isn't a milestone - use `%s`, or a number of minutes left between %d and %d", part, milestoneHalfway, minWorkMinutes, maxWorkMinutes)
+		}
+		milestones = append(milestones, pomodoro.Milestone{Remaining: time.Duration(minutes) * time.Minute})
+	}
+
+	if len(milestones) > maxMilestones {
+		return nil, fmt.Errorf("you can set up to %d milestones", maxMilestones)
+	}
+	return milestones, nil
+}
+
+// formatMilestones describes the milestones for display in Discord.
+func formatMilestones(milestones []pomodoro.Milestone) string {
+	if len(milestones) == 0 {
+		return milestonesOffName
+	}
+
+	descriptions := make([]string, 0, len(milestones))
+	for _, m := range milestones {
+		switch {
+		case m.Remaining > 0:
+			descriptions = append(descriptions, formatMinutes(m.Remaining)+" left")
+		case m.Fraction == 0.5:
+			descriptions = append(descriptions, "halfway")
+		default:
+			descriptions = append(descriptions, fmt.Sprintf("%.0f%% left", m.Fraction*100))
+		}
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// formatMinutes describes the duration as a whole number of minutes, rounding up so that a minute left isn't shown as 0.
+func formatMinutes(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
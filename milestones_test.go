@@ -0,0 +1,27 @@
+package coffeebeanbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestParseMilestones(t *testing.T) {
+	milestones, err := parseMilestones(" Half, 5,1 ")
+	ExpectedActual(t, nil, err, "parsing milestones")
+	ExpectedActual(t, []pomodoro.Milestone{{Fraction: 0.5}, {Remaining: time.Minute * 5}, {Remaining: time.Minute}}, milestones, "milestones")
+	ExpectedActual(t, "halfway, 5 minutes left, 1 minute left", formatMilestones(milestones), "formatted milestones")
+
+	milestones, err = parseMilestones("none")
+	ExpectedActual(t, nil, err, "parsing none")
+	ExpectedActual(t, 0, len(milestones), "no milestones")
+	ExpectedActual(t, "none", formatMilestones(milestones), "formatted no milestones")
+
+	for _, invalid := range []string{"soon", "0", "5,,1", "1,2,3,4,5,6"} {
+		if _, err := parseMilestones(invalid); err == nil {
+			t.Errorf("Expected an error for invalid milestones %q", invalid)
+		}
+	}
+}
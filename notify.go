@@ -0,0 +1,54 @@
+package coffeebeanbot
+
+import "slices"
+
+// NotifyInfo is the payload of the bot's Pomodoros. It contains the necessary information to notify the creating user,
+// and anyone who joined them, as the Pomodoro progresses and ends.
+type NotifyInfo struct {
+	Title        string   // The title of the work task
+	UserID       string   // The UserID of the user who created the Pomodoro
+	GuildID      string   // The Guild (Discord server) that the user created the Pomodoro on, or empty if in a DM
+	ChannelID    string   // The Channel to notify with the state of the Pomodoro
+	Participants []string // The UserIDs of the other users who have joined the Pomodoro, in the order they joined
+	MessageID    string   // The message showing the live status of the Pomodoro, if any
+}
+
+// Users returns the UserIDs of everyone taking part in the Pomodoro - its creator followed by the participants.
+func (n NotifyInfo) Users() []string {
+	return append([]string{n.UserID}, n.Participants...)
+}
+
+// HasUser returns whether the user is taking part in the Pomodoro, either as its creator or a participant.
+func (n NotifyInfo) HasUser(userID string) bool {
+	return n.UserID == userID || slices.Contains(n.Participants, userID)
+}
+
+// Join adds the user to the participants, so they are included in all later callbacks. Returns false if the user is
+// already taking part. Use it with ChannelPomMap.Update.
+func (n *NotifyInfo) Join(userID string) bool {
+	if n.HasUser(userID) {
+		return false
+	}
+	// The NotifyInfo has been handed out to callbacks, so we never modify its existing slice
+	n.Participants = append(slices.Clip(n.Participants), userID)
+	return true
+}
+
+// Leave removes the user from the participants. The user who created the Pomodoro can't leave it. Returns false if the
+// user isn't a participant. Use it with ChannelPomMap.Update.
+func (n *NotifyInfo) Leave(userID string) bool {
+	if !slices.Contains(n.Participants, userID) {
+		return false
+	}
+	n.Participants = slices.DeleteFunc(slices.Clone(n.Participants), func(id string) bool {
+		return id == userID
+	})
+	return true
+}
+
+// SetMessageID sets the ID of the message showing the live status of the Pomodoro, so it is included in all later
+// callbacks. Always returns true, for use with ChannelPomMap.Update.
+func (n *NotifyInfo) SetMessageID(messageID string) bool {
+	n.MessageID = messageID
+	return true
+}
@@ -0,0 +1,25 @@
+package coffeebeanbot
+
+import (
+	"testing"
+
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestNotifyInfoJoinLeave(t *testing.T) {
+	info := NotifyInfo{UserID: "Owner"}
+	handedOut := info
+
+	ExpectedActual(t, false, info.Join("Owner"), "owner joining")
+	ExpectedActual(t, true, info.Join("Joiner"), "joining")
+	ExpectedActual(t, false, info.Join("Joiner"), "joining twice")
+	ExpectedActual(t, true, info.Join("Leaver"), "joining to leave")
+	ExpectedActual(t, false, info.Leave("Owner"), "owner leaving")
+	ExpectedActual(t, true, info.Leave("Leaver"), "leaving")
+	ExpectedActual(t, false, info.Leave("Leaver"), "leaving twice")
+
+	ExpectedActual(t, []string{"Owner", "Joiner"}, info.Users(), "users")
+	ExpectedActual(t, true, info.HasUser("Joiner"), "has joiner")
+	ExpectedActual(t, false, info.HasUser("Leaver"), "has leaver")
+	ExpectedActual(t, 0, len(handedOut.Participants), "participants of the copy handed out")
+}
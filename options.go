@@ -0,0 +1,76 @@
+package coffeebeanbot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// The bounds for the options of our app cmds. Durations are in minutes unless otherwise noted.
+const (
+	minWorkMinutes      = 1
+	maxWorkMinutes      = 180
+	minBreakMinutes     = 1
+	maxBreakMinutes     = 60
+	minLongBreakMinutes = 1
+	maxLongBreakMinutes = 120
+	minExtendMinutes    = 1
+	maxExtendMinutes    = 60
+
+	defaultExtension = time.Minute * 5 // The time added by the +5 button, and by /pomextend if no minutes are given
+	maxPauseTime     = time.Hour * 4   // How long a Pomodoro can stay paused before it's cancelled
+	maxReasonLength  = 200             // The longest reason that can be given to /pomcancel
+)
+
+// optionGetter is implemented by both the top-level data of an app cmd and its subcommands, so the helpers below can
+// be used with either.
+type optionGetter interface {
+	GetOption(name string) *discordgo.ApplicationCommandInteractionDataOption
+}
+
+// minutesOption creates an optional integer app cmd option for a number of minutes within [min, max].
+func minutesOption(name, description string, min, max int) *discordgo.ApplicationCommandOption {
+	minValue := float64(min)
+	return &discordgo.ApplicationCommandOption{
+		Name:        name,
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Description: fmt.Sprintf("%s (%d-%d minutes)", description, min, max),
+		MinValue:    &minValue,
+		MaxValue:    float64(max),
+	}
+}
+
+// pomUserOption creates the optional user app cmd option that picks whose Pomodoro to act on, for when everyone on the
+// channel runs their own. See Bot.pomKey.
+func pomUserOption(action string) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Name:        "user",
+		Type:        discordgo.ApplicationCommandOptionUser,
+		Description: fmt.Sprintf("Whose Pomodoro to %s, if everyone on the channel runs their own", action),
+	}
+}
+
+// optionString returns the value of the named string option, or "" if it was not given.
+func optionString(data optionGetter, name string) string {
+	if opt := data.GetOption(name); opt != nil {
+		return opt.StringValue()
+	}
+	return ""
+}
+
+// optionMinutes returns the value of the named minutes option as a duration, or def if it was not given.
+// Discord enforces the bounds we registered, but we also check them here rather than trusting the client.
+func optionMinutes(data optionGetter, name string, min, max int, def time.Duration) (time.Duration, error) {
+	opt := data.GetOption(name)
+	if opt == nil {
+		return def, nil
+	}
+
+	minutes := opt.IntValue()
+	if minutes < int64(min) || minutes > int64(max) {
+		return def, fmt.Errorf("`%s` must be between %d and %d minutes", name, min, max)
+	}
+
+	return time.Duration(minutes) * time.Minute, nil
+}
@@ -0,0 +1,115 @@
+package coffeebeanbot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+)
+
+const maxModRoles = 10 // The most roles a Guild can allow to cancel or change any Pomodoro
+
+// canControl returns whether the user may cancel or change the Pomodoro with the given key, under the Guild's control
+// policy. If they can't, they're told why. If no Pomodoro is running, this returns true and leaves it to the action to
+// say so.
+func (bot *Bot) canControl(s *discordgo.Session, i *discordgo.Interaction, key pomodoro.Key) bool {
+	user := interactionUser(i)
+	if user == nil {
+		return false
+	}
+
+	status, exists := bot.poms.Status(key)
+	if !exists {
+		return true
+	}
+
+	settings := bot.guildSettings(status.Info.GuildID)
+	if mayControl(settings, status.Info, i.Member, user.ID) {
+		return true
+	}
+
+	respond(s, i, controlRefusal(settings.ControlPolicy), flagEphemeral)
+	return false
+}
+
+// mayControl returns whether the user, who is the given member in Guilds, may cancel or change the Pomodoro under the
+// Guild's settings. Moderators may always do so.
+func mayControl(settings store.GuildSettings, info NotifyInfo, member *discordgo.Member, userID string) bool {
+	switch settings.ControlPolicy {
+	case store.ControlAnyone:
+		return true
+	case store.ControlStarter:
+		if userID == info.UserID {
+			return true
+		}
+	default:
+		if info.HasUser(userID) {
+			return true
+		}
+	}
+
+	return isModerator(settings, member)
+}
+
+// isModerator returns whether the member can manage messages, or has one of the Guild's moderator roles.
+func isModerator(settings store.GuildSettings, member *discordgo.Member) bool {
+	if member == nil {
+		return false
+	}
+	if member.Permissions&discordgo.PermissionManageMessages != 0 {
+		return true
+	}
+	return slices.ContainsFunc(member.Roles, func(roleID string) bool {
+		return slices.Contains(settings.ModRoleIDs, roleID)
+	})
+}
+
+// cancelOutcome returns how a Pomodoro cancelled by the user, who is the given member in Guilds, ends. It's only an
+// admin cancel if they're a moderator who isn't taking part, rather than anyone the policy lets cancel it.
+func cancelOutcome(settings store.GuildSettings, info NotifyInfo, member *discordgo.Member, userID string) pomodoro.Outcome {
+	if !info.HasUser(userID) && isModerator(settings, member) {
+		return pomodoro.OutcomeAdminCancelled
+	}
+	return pomodoro.OutcomeCancelled
+}
+
+// controlRefusal explains who can cancel or change a Pomodoro under the policy.
+func controlRefusal(policy string) string {
+	if policy == store.ControlStarter {
+		return "Only the person who started this Pomodoro, or a moderator, can do that."
+	}
+	return "Only those taking part in this Pomodoro, or a moderator, can do that.  Use `/" + joinCmdName + "` to take part."
+}
+
+// formatControlPolicy describes who can cancel or change a Pomodoro, for display in Discord.
+func formatControlPolicy(settings store.GuildSettings) string {
+	who := "those taking part"
+	switch settings.ControlPolicy {
+	case store.ControlStarter:
+		who = "the person who started it"
+	case store.ControlAnyone:
+		who = "anyone"
+	}
+
+	moderators := "moderators"
+	if len(settings.ModRoleIDs) > 0 {
+		moderators += " and <@&" + strings.Join(settings.ModRoleIDs, ">, <@&") + ">"
+	}
+	return who + ", " + moderators
+}
+
+// toggleModRole adds the role to the Guild's moderator roles, or removes it if it's already one of them.
+func toggleModRole(settings store.GuildSettings, roleID string) (store.GuildSettings, error) {
+	if i := slices.Index(settings.ModRoleIDs, roleID); i >= 0 {
+		settings.ModRoleIDs = slices.Delete(slices.Clone(settings.ModRoleIDs), i, i+1)
+		return settings, nil
+	}
+	if len(settings.ModRoleIDs) >= maxModRoles {
+		return settings, fmt.Errorf("you can set up to %d moderator roles", maxModRoles)
+	}
+	settings.ModRoleIDs = append(slices.Clone(settings.ModRoleIDs), roleID)
+	return settings, nil
+}
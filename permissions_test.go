@@ -0,0 +1,67 @@
+package coffeebeanbot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestMayControl(t *testing.T) {
+	info := NotifyInfo{UserID: "starter", Participants: []string{"joiner"}}
+	member := &discordgo.Member{Roles: []string{"RegularRole"}}
+	settings := store.DefaultGuildSettings()
+
+	ExpectedActual(t, true, mayControl(settings, info, member, "starter"), "starter with participants policy")
+	ExpectedActual(t, true, mayControl(settings, info, member, "joiner"), "participant with participants policy")
+	ExpectedActual(t, false, mayControl(settings, info, member, "troll"), "outsider with participants policy")
+	ExpectedActual(t, false, mayControl(settings, info, nil, "troll"), "outsider outside a Guild")
+
+	settings.ControlPolicy = ""
+	ExpectedActual(t, false, mayControl(settings, info, member, "troll"), "outsider with unset policy")
+
+	settings.ControlPolicy = store.ControlStarter
+	ExpectedActual(t, true, mayControl(settings, info, member, "starter"), "starter with starter policy")
+	ExpectedActual(t, false, mayControl(settings, info, member, "joiner"), "participant with starter policy")
+
+	settings.ControlPolicy = store.ControlAnyone
+	ExpectedActual(t, true, mayControl(settings, info, member, "troll"), "outsider with anyone policy")
+
+	// Moderators can always control it
+	settings.ControlPolicy = store.ControlStarter
+	ExpectedActual(t, true, mayControl(settings, info, &discordgo.Member{Permissions: discordgo.PermissionManageMessages}, "mod"), "member who can manage messages")
+	settings.ModRoleIDs = []string{"ModRole"}
+	ExpectedActual(t, true, mayControl(settings, info, &discordgo.Member{Roles: []string{"RegularRole", "ModRole"}}, "mod"), "member with a mod role")
+	ExpectedActual(t, false, mayControl(settings, info, member, "troll"), "member without a mod role")
+}
+
+func TestCancelOutcome(t *testing.T) {
+	info := NotifyInfo{UserID: "starter", Participants: []string{"joiner"}}
+	settings := store.DefaultGuildSettings()
+	settings.ControlPolicy = store.ControlAnyone
+	mod := &discordgo.Member{Permissions: discordgo.PermissionManageMessages}
+
+	ExpectedActual(t, pomodoro.OutcomeCancelled, cancelOutcome(settings, info, nil, "joiner"), "participant cancelling")
+	ExpectedActual(t, pomodoro.OutcomeCancelled, cancelOutcome(settings, info, mod, "starter"), "moderator taking part cancelling")
+	ExpectedActual(t, pomodoro.OutcomeCancelled, cancelOutcome(settings, info, &discordgo.Member{}, "passerby"), "outsider cancelling with anyone policy")
+	ExpectedActual(t, pomodoro.OutcomeAdminCancelled, cancelOutcome(settings, info, mod, "mod"), "moderator cancelling")
+}
+
+func TestToggleModRole(t *testing.T) {
+	settings, err := toggleModRole(store.DefaultGuildSettings(), "ModRole")
+	ExpectedActual(t, nil, err, "adding a mod role")
+	ExpectedActual(t, []string{"ModRole"}, settings.ModRoleIDs, "mod roles after adding")
+
+	settings, _ = toggleModRole(settings, "ModRole")
+	ExpectedActual(t, 0, len(settings.ModRoleIDs), "mod roles after removing")
+
+	for range maxModRoles {
+		settings.ModRoleIDs = append(settings.ModRoleIDs, "AnotherRole")
+	}
+	_, err = toggleModRole(settings, "OneTooMany")
+	if err == nil {
+		t.Error("Expected an error adding too many mod roles")
+	}
+}
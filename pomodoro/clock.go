@@ -0,0 +1,168 @@
+package pomodoro
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and makes timers for Pomodoros. RealClock uses the time package, while FakeClock lets tests move
+// time along by hand, so they needn't wait for it.
+type Clock interface {
+	Now() time.Time
+	// TimerAt returns a timer that fires once the clock reaches at, or as soon as possible if it already has. Timers are
+	// set for a time rather than a duration, so a deadline can't drift while the timer is being made.
+	TimerAt(at time.Time) Timer
+}
+
+// Timer is a single-use timer made by a Clock, similar to time.Timer.
+type Timer interface {
+	C() <-chan time.Time // Receives the time once the timer fires
+	Stop() bool          // Stops the timer, returning false if it has already fired or been stopped
+}
+
+// maxTimerWait is the longest a real timer waits before checking the time again. Go's timers run on the monotonic
+// clock, which stops while the host is asleep and ignores changes to the wall clock, so a long timer could otherwise
+// fire well after its deadline.
+const maxTimerWait = time.Second * 10
+
+// RealClock is the Clock of the time package. It only uses the wall clock, so deadlines are kept to even if the host
+// sleeps or its clock is changed. Times are stripped of their monotonic clock readings, which would otherwise be used to
+// compare them.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now().Round(0)
+}
+
+func (RealClock) TimerAt(at time.Time) Timer {
+	t := &realTimer{at: at.Round(0), c: make(chan time.Time, 1)}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.wait()
+	return t
+}
+
+// realTimer is a Timer made by RealClock, which checks the wall clock at least every maxTimerWait until it's due.
+type realTimer struct {
+	at    time.Time
+	c     chan time.Time
+	mutex sync.Mutex
+	timer *time.Timer // Fires at the deadline or the next check, whichever is sooner
+	done  bool        // Whether the timer has fired or been stopped
+}
+
+func (t *realTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *realTimer) Stop() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.done {
+		return false
+	}
+	t.done = true
+	t.timer.Stop()
+	return true
+}
+
+// wait sets the underlying timer for the next check. The mutex must be held.
+func (t *realTimer) wait() {
+	t.timer = time.AfterFunc(min(time.Until(t.at), maxTimerWait), t.check)
+}
+
+// check fires the timer if the deadline has been reached, or waits again if it hasn't.
+func (t *realTimer) check() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.done {
+		return
+	}
+	now := time.Now().Round(0)
+	if now.Before(t.at) {
+		t.wait()
+		return
+	}
+	t.done = true
+	t.c <- now
+}
+
+// FakeClock is a Clock for tests, which only moves when told to with Advance.
+//
+// This type is goroutine-safe.
+type FakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer // Timers that haven't fired or been stopped yet
+}
+
+// NewFakeClock creates a FakeClock that starts at the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) TimerAt(at time.Time) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t := &fakeTimer{clock: c, at: at, c: make(chan time.Time, 1)}
+	if at.After(c.now) {
+		c.timers = append(c.timers, t)
+	} else {
+		t.c <- c.now
+	}
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer that's due by then in the order they're due. Anything waiting
+// on those timers runs on its own goroutine, so callers should wait for its effects, such as a callback being called,
+// before advancing again.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+	slices.SortStableFunc(c.timers, func(a, b *fakeTimer) int {
+		return a.at.Compare(b.at)
+	})
+	due := 0
+	for due < len(c.timers) && !c.timers[due].at.After(c.now) {
+		c.timers[due].c <- c.now
+		due++
+	}
+	c.timers = slices.Delete(c.timers, 0, due)
+}
+
+// fakeTimer is a Timer made by a FakeClock.
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	c     chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	i := slices.Index(t.clock.timers, t)
+	if i < 0 {
+		return false
+	}
+	t.clock.timers = slices.Delete(t.clock.timers, i, i+1)
+	return true
+}
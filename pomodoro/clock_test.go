@@ -0,0 +1,52 @@
+package pomodoro
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	later := clock.TimerAt(testStart.Add(time.Minute * 2))
+	sooner := clock.TimerAt(testStart.Add(time.Minute))
+	stopped := clock.TimerAt(testStart.Add(time.Minute))
+	ExpectedActual(t, true, stopped.Stop(), "stopping a pending timer")
+	ExpectedActual(t, false, stopped.Stop(), "stopping a stopped timer")
+
+	clock.Advance(time.Second * 30)
+	ExpectedActual(t, testStart.Add(time.Second*30), clock.Now(), "time after advancing")
+	ExpectedActual(t, 0, len(sooner.C()), "timers fired before they're due")
+
+	// Advancing past a timer fires it with the new time, not the time it was due
+	clock.Advance(time.Minute * 2)
+	ExpectedActual(t, testStart.Add(time.Second*150), <-sooner.C(), "time from the sooner timer")
+	ExpectedActual(t, testStart.Add(time.Second*150), <-later.C(), "time from the later timer")
+	ExpectedActual(t, 0, len(stopped.C()), "stopped timer firing")
+	ExpectedActual(t, false, later.Stop(), "stopping a fired timer")
+
+	// Timers that are already due fire straight away
+	past := clock.TimerAt(testStart)
+	ExpectedActual(t, clock.Now(), <-past.C(), "time from a timer that's already due")
+}
+
+func TestRealClock(t *testing.T) {
+	clock := RealClock{}
+	now := clock.Now()
+	// Without a monotonic clock reading, times are compared using the wall clock alone
+	ExpectedActual(t, now.Round(0), now, "time from the real clock")
+
+	past := clock.TimerAt(now.Add(-time.Minute))
+	ExpectedActual(t, false, (<-past.C()).Before(now), "timer that's already due firing early")
+	ExpectedActual(t, false, past.Stop(), "stopping a fired timer")
+
+	at := clock.Now().Add(time.Millisecond * 20)
+	soon := clock.TimerAt(at)
+	ExpectedActual(t, false, (<-soon.C()).Before(at), "timer firing early")
+
+	stopped := clock.TimerAt(clock.Now().Add(time.Millisecond * 20))
+	ExpectedActual(t, true, stopped.Stop(), "stopping a pending timer")
+	time.Sleep(time.Millisecond * 40)
+	ExpectedActual(t, 0, len(stopped.C()), "stopped timer firing")
+}
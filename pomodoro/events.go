@@ -0,0 +1,192 @@
+package pomodoro
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of change in a Pomodoro's lifecycle that an Event describes.
+type EventType int
+
+const (
+	EventStarted      EventType = iota // The Pomodoro was started, or restored from a Snapshot
+	EventPaused                        // The Pomodoro was paused
+	EventResumed                       // The Pomodoro was resumed
+	EventExtended                      // The current phase was extended
+	EventMilestone                     // A milestone was reached during a work round
+	EventPhaseChanged                  // A phase ended and the next one began
+	EventCompleted                     // The final phase ended
+	EventCancelled                     // The Pomodoro ended early, for any reason other than completing
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventStarted:
+		return "started"
+	case EventPaused:
+		return "paused"
+	case EventResumed:
+		return "resumed"
+	case EventExtended:
+		return "extended"
+	case EventMilestone:
+		return "milestone"
+	case EventPhaseChanged:
+		return "phase_changed"
+	case EventCompleted:
+		return "completed"
+	case EventCancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a change in the lifecycle of a Pomodoro in a ChannelPomMap, as sent to its subscribers. Only the fields for
+// its type are set, along with those common to all events.
+type Event[T any] struct {
+	Type    EventType
+	Key     Key       // The Pomodoro's key in the map
+	Info    T         // The Pomodoro's payload at the time
+	Time    time.Time // When it happened
+	Running int       // How many Pomodoros the map was running just after it happened
+
+	Status     Status[T]     // The Pomodoro's state just after it happened, for all but EventCompleted and EventCancelled
+	Restored   bool          // For EventStarted, whether the Pomodoro was restored from a Snapshot
+	Extension  time.Duration // For EventExtended, how much the phase was extended by
+	Milestone  Milestone     // For EventMilestone, the milestone reached
+	Transition Transition    // For EventPhaseChanged, the change of phase
+	Result     Result        // For EventCompleted and EventCancelled, how the Pomodoro ended
+}
+
+// Ended returns whether the event is the end of the Pomodoro.
+func (e Event[T]) Ended() bool {
+	return e.Type == EventCompleted || e.Type == EventCancelled
+}
+
+// Subscribe calls handle with every event from the map's Pomodoros from now on, until the returned function is called.
+// Each subscriber has its own goroutine that's given events in the order they happened, so a slow subscriber doesn't
+// hold up the others or the Pomodoros. Events are queued until it's ready for them.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Subscribe(handle func(e Event[T])) (unsubscribe func()) {
+	sub := &subscriber[T]{
+		handle:  handle,
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		drained: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	m.subsMutex.Lock()
+	m.subs = append(m.subs, sub)
+	m.subsMutex.Unlock()
+	go sub.run()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.subsMutex.Lock()
+			m.subs = slices.DeleteFunc(slices.Clone(m.subs), func(s *subscriber[T]) bool { return s == sub })
+			m.subsMutex.Unlock()
+			close(sub.stop)
+		})
+	}
+}
+
+// publish queues the event for every subscriber.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) publish(e Event[T]) {
+	m.subsMutex.Lock()
+	defer m.subsMutex.Unlock()
+
+	for _, sub := range m.subs {
+		sub.push(e)
+	}
+}
+
+// drainSubscribers removes every subscriber, and waits for each to handle the events already queued for it.
+func (m *ChannelPomMap[T]) drainSubscribers() {
+	m.subsMutex.Lock()
+	subs := m.subs
+	m.subs = nil
+	m.subsMutex.Unlock()
+
+	for _, sub := range subs {
+		close(sub.drained)
+		<-sub.done
+	}
+}
+
+// publisher returns the function that publishes the events of the Pomodoro with the given key. It's called on the
+// scheduler's goroutine, which is also where the map's count of running Pomodoros is kept.
+func (m *ChannelPomMap[T]) publisher(key Key) func(e Event[T]) {
+	return func(e Event[T]) {
+		switch {
+		case e.Type == EventStarted:
+			m.running++
+		case e.Ended():
+			m.running--
+		}
+		e.Key, e.Running = key, m.running
+		m.publish(e)
+	}
+}
+
+// subscriber queues events for a handler, which it runs on its own goroutine.
+type subscriber[T any] struct {
+	handle  func(e Event[T])
+	mutex   sync.Mutex
+	queue   []Event[T]    // Events that haven't been handled yet, oldest first
+	wake    chan struct{} // Signalled when events are queued
+	stop    chan struct{} // Closed when unsubscribed
+	drained chan struct{} // Closed to stop once the events already queued have been handled
+	done    chan struct{} // Closed once the subscriber has stopped
+}
+
+// push queues the event for the handler, without waiting for it.
+func (sub *subscriber[T]) push(e Event[T]) {
+	sub.mutex.Lock()
+	sub.queue = append(sub.queue, e)
+	sub.mutex.Unlock()
+
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+		// It's already been woken, and will take this event along with the others
+	}
+}
+
+// run handles events as they're queued, until unsubscribed or drained. Any events still queued when unsubscribed are
+// dropped.
+func (sub *subscriber[T]) run() {
+	defer close(sub.done)
+	for {
+		draining := false
+		select {
+		case <-sub.wake:
+		case <-sub.drained:
+			draining = true
+		case <-sub.stop:
+			return
+		}
+
+		sub.mutex.Lock()
+		events := sub.queue
+		sub.queue = nil
+		sub.mutex.Unlock()
+
+		for _, e := range events {
+			select {
+			case <-sub.stop:
+				return
+			default:
+				sub.handle(e)
+			}
+		}
+		if draining {
+			return
+		}
+	}
+}
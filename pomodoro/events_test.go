@@ -0,0 +1,143 @@
+package pomodoro
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestPomMapEvents(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	cpm := NewChannelPomMapWithScheduler[testInfo](newTestScheduler(t, clock, testWorkers))
+	key := Key{ChannelID: "TheChannel"}
+	info := testInfo{Title: "Hear all about it", ChannelID: key.ChannelID}
+	settings := Settings{
+		Work:              time.Minute * 20,
+		ShortBreak:        time.Minute * 5,
+		LongBreakInterval: 2,
+		Milestones:        []Milestone{{Fraction: 0.5}},
+	}
+	events := make(chan Event[testInfo], 16)
+	cpm.Subscribe(func(e Event[testInfo]) { events <- e })
+	// Every subscriber gets every event
+	others := make(chan Event[testInfo], 16)
+	cpm.Subscribe(func(e Event[testInfo]) { others <- e })
+
+	// Events are published as they happen, so each is waited for before moving on, like the callbacks in other tests
+	next := func(expected EventType) Event[testInfo] {
+		e := <-events
+		ExpectedActual(t, expected, e.Type, "event type")
+		ExpectedActual(t, key, e.Key, fmt.Sprintf("%s event key", expected))
+		ExpectedActual(t, info, e.Info, fmt.Sprintf("%s event info", expected))
+		ExpectedActual(t, clock.Now(), e.Time, fmt.Sprintf("%s event time", expected))
+		return e
+	}
+
+	cpm.CreateIfEmpty(key, settings, nil, nil, nil, info)
+	e := next(EventStarted)
+	ExpectedActual(t, false, e.Restored, "restored on start")
+	ExpectedActual(t, 1, e.Running, "running after start")
+	ExpectedActual(t, settings.Work, e.Status.Remaining, "remaining time on start")
+
+	cpm.Pause(key)
+	ExpectedActual(t, true, next(EventPaused).Status.Paused, "paused status")
+	cpm.Resume(key)
+	ExpectedActual(t, false, next(EventResumed).Status.Paused, "resumed status")
+	cpm.Extend(key, time.Minute*10)
+	e = next(EventExtended)
+	ExpectedActual(t, time.Minute*10, e.Extension, "extension")
+	ExpectedActual(t, time.Minute*30, e.Status.Remaining, "remaining time after extending")
+
+	// Milestones are reached without a milestone callback, since there's a subscriber
+	clock.Advance(time.Minute * 15)
+	e = next(EventMilestone)
+	ExpectedActual(t, settings.Milestones[0], e.Milestone, "milestone reached")
+	ExpectedActual(t, time.Minute*15, e.Status.Remaining, "remaining time at milestone")
+
+	clock.Advance(time.Minute * 15)
+	e = next(EventPhaseChanged)
+	ExpectedActual(t, PhaseWork, e.Transition.Ended, "phase ended")
+	ExpectedActual(t, PhaseShortBreak, e.Status.Phase, "phase after change")
+
+	clock.Advance(time.Minute * 5)
+	next(EventPhaseChanged)
+	clock.Advance(time.Minute * 10)
+	next(EventMilestone)
+	clock.Advance(time.Minute * 10)
+	e = next(EventCompleted)
+	ExpectedActual(t, true, e.Result.Completed(), "completed result")
+	ExpectedActual(t, 0, e.Running, "running after completion")
+
+	// Cancelling
+	cpm.CreateIfEmpty(key, settings, nil, nil, nil, info)
+	next(EventStarted)
+	cpm.RemoveIfExists(key, OutcomeCancelled, "Lunch")
+	e = next(EventCancelled)
+	ExpectedActual(t, OutcomeCancelled, e.Result.Outcome, "cancelled outcome")
+	ExpectedActual(t, "Lunch", e.Result.Reason, "cancelled reason")
+	ExpectedActual(t, 0, e.Running, "running after cancelling")
+
+	expected := []EventType{
+		EventStarted, EventPaused, EventResumed, EventExtended, EventMilestone, EventPhaseChanged, EventPhaseChanged,
+		EventMilestone, EventCompleted, EventStarted, EventCancelled,
+	}
+	for i, exp := range expected {
+		ExpectedActual(t, exp, (<-others).Type, fmt.Sprintf("other subscriber's event %d", i))
+	}
+}
+
+func TestPomMapUnsubscribe(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	cpm := NewChannelPomMapWithScheduler[testInfo](newTestScheduler(t, clock, testWorkers))
+	key := Key{ChannelID: "TheChannel"}
+	events := make(chan Event[testInfo], 4)
+	unsubscribe := cpm.Subscribe(func(e Event[testInfo]) { events <- e })
+	// A second subscriber tells us when events have been published
+	published := make(chan Event[testInfo], 4)
+	cpm.Subscribe(func(e Event[testInfo]) { published <- e })
+
+	cpm.CreateIfEmpty(key, Settings{Work: time.Minute}, nil, nil, nil, testInfo{})
+	<-published
+	ExpectedActual(t, EventStarted, (<-events).Type, "event before unsubscribing")
+
+	unsubscribe()
+	unsubscribe()
+	cpm.Pause(key)
+	<-published
+	ExpectedActual(t, 0, len(events), "events after unsubscribing")
+	cpm.RemoveIfExists(key, OutcomeCancelled, "")
+}
+
+func TestPomMapRestoreEvents(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	cpm := NewChannelPomMapWithScheduler[testInfo](newTestScheduler(t, clock, testWorkers))
+	key := Key{ChannelID: "TheChannel"}
+	events := make(chan Event[testInfo], 4)
+	cpm.Subscribe(func(e Event[testInfo]) { events <- e })
+
+	snap := Snapshot[testInfo]{
+		Settings: Settings{Work: time.Minute * 25},
+		Info:     testInfo{Title: "Back again", ChannelID: key.ChannelID},
+		Started:  testStart,
+		Phase:    PhaseWork,
+		Round:    1,
+		Deadline: testStart.Add(time.Minute * 25),
+		Key:      key,
+	}
+	cpm.Restore(snap, nil, nil, nil)
+	e := <-events
+	ExpectedActual(t, EventStarted, e.Type, "event on restore")
+	ExpectedActual(t, true, e.Restored, "restored")
+
+	// There's already a Pomodoro with its key, so it can't be restored
+	snap.Info.Title = "Crowded out"
+	ExpectedActual(t, false, cpm.Restore(snap, nil, nil, nil), "restoring over a running Pomodoro")
+	e = <-events
+	ExpectedActual(t, EventCancelled, e.Type, "event on failing to restore")
+	ExpectedActual(t, snap.Info, e.Info, "info of the Pomodoro that couldn't be restored")
+	ExpectedActual(t, OutcomeRestart, e.Result.Outcome, "outcome of the Pomodoro that couldn't be restored")
+	ExpectedActual(t, 1, e.Running, "running after failing to restore")
+	cpm.RemoveIfExists(key, OutcomeCancelled, "")
+}
@@ -8,15 +8,14 @@ import (
 func ExampleNewPomodoro() {
 	// This channel will prevent us from exiting the test before our Pomodoro has completed
 	c := make(chan bool)
-	onTestEnd := func(notify NotifyInfo, completed bool) {
-		if completed {
-			fmt.Printf("Work '%s' done!\n", notify.Title)
+	onTestEnd := func(title string, result Result) {
+		if result.Completed() {
+			fmt.Printf("Work '%s' done!\n", title)
 		}
 		c <- true
 	}
 
-	notify := NotifyInfo{Title: "Create example"}
-	NewPomodoro(time.Millisecond*2, onTestEnd, notify)
+	NewPomodoro(time.Millisecond*2, onTestEnd, "Create example")
 
 	<-c
 	fmt.Println("Exiting test.")
@@ -25,3 +24,22 @@ func ExampleNewPomodoro() {
 	// Work 'Create example' done!
 	// Exiting test.
 }
+
+func ExampleNewScheduler() {
+	clock := NewFakeClock(time.Date(2024, time.March, 14, 9, 0, 0, 0, time.UTC))
+	sched := NewScheduler(clock, 1)
+	defer sched.Stop()
+	poms := NewChannelPomMapWithScheduler[string](sched)
+	c := make(chan bool)
+	poms.CreateIfEmpty(Key{UserID: "cli"}, Settings{Work: time.Minute * 25}, nil, nil, func(info string, result Result) {
+		fmt.Printf("%s %s\n", info, result.Outcome)
+		c <- true
+	}, "Write docs")
+
+	// No need to wait 25 minutes
+	clock.Advance(time.Minute * 25)
+	<-c
+
+	// Output:
+	// Write docs completed
+}
@@ -0,0 +1,77 @@
+package pomodoro
+
+// Key identifies a Pomodoro in a ChannelPomMap. Which of its fields are set depends on the KeyMode that made it.
+type Key struct {
+	ChannelID string // The channel the Pomodoro is on, unless it's keyed by user alone
+	UserID    string // The user who started the Pomodoro, unless it's keyed by channel alone
+}
+
+// KeyMode decides which Pomodoros share a Key, and so how many can run at once.
+type KeyMode int
+
+const (
+	KeyPerChannel       KeyMode = iota // One Pomodoro per channel, shared by everyone on it
+	KeyPerUserInChannel                // One Pomodoro per user on each channel, so people can run their own on a shared channel
+	KeyPerUser                         // One Pomodoro per user, whichever channel it's on
+)
+
+// KeyModes lists every KeyMode, in order.
+var KeyModes = []KeyMode{KeyPerChannel, KeyPerUserInChannel, KeyPerUser}
+
+func (mode KeyMode) String() string {
+	switch mode {
+	case KeyPerChannel:
+		return "channel"
+	case KeyPerUserInChannel:
+		return "user_in_channel"
+	case KeyPerUser:
+		return "user"
+	default:
+		return "unknown"
+	}
+}
+
+// Key returns the key of the Pomodoro the user would start on the channel under this mode.
+func (mode KeyMode) Key(channelID, userID string) Key {
+	switch mode {
+	case KeyPerUserInChannel:
+		return Key{ChannelID: channelID, UserID: userID}
+	case KeyPerUser:
+		return Key{UserID: userID}
+	default:
+		return Key{ChannelID: channelID}
+	}
+}
+
+// Lookup returns the key of the Pomodoro the user means on the channel under the given mode. Under KeyPerChannel this is
+// the channel's Pomodoro. Otherwise it's the one the user started, or failing that, one whose payload joined says the user
+// has joined on the channel. If there is none, it returns the key the user would start one with, and false.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Lookup(mode KeyMode, channelID, userID string, joined func(info T) bool) (Key, bool) {
+	key := mode.Key(channelID, userID)
+	if m.get(key) != nil {
+		return key, true
+	}
+	if mode == KeyPerChannel {
+		return key, false
+	}
+
+	if joinedKey, ok := m.Find(func(status Status[T]) bool { return joined(status.Info) }); ok {
+		return joinedKey, true
+	}
+	return key, false
+}
+
+// Find returns the key of a Pomodoro whose status matches, or false if none do. If more than one matches, which is
+// returned is unspecified.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Find(match func(status Status[T]) bool) (Key, bool) {
+	for key, p := range m.all() {
+		if status, ok := p.Status(); ok && match(status) {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
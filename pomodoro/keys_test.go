@@ -0,0 +1,67 @@
+package pomodoro
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestKeyModeKey(t *testing.T) {
+	ExpectedActual(t, Key{ChannelID: "Chan"}, KeyPerChannel.Key("Chan", "User"), "per channel key")
+	ExpectedActual(t, Key{ChannelID: "Chan", UserID: "User"}, KeyPerUserInChannel.Key("Chan", "User"), "per user in channel key")
+	ExpectedActual(t, Key{UserID: "User"}, KeyPerUser.Key("Chan", "User"), "per user key")
+}
+
+// joinedOn returns a function for Lookup that reports whether the user has joined a Pomodoro on the channel.
+func joinedOn(channelID, userID string) func(info testInfo) bool {
+	return func(info testInfo) bool {
+		return info.ChannelID == channelID && (info.UserID == userID || slices.Contains(info.Participants, userID))
+	}
+}
+
+func TestPomMapLookup(t *testing.T) {
+	cpm := NewChannelPomMap[testInfo]()
+	settings := Settings{Work: time.Second}
+	onEnd := func(testInfo, Result) {}
+
+	// Two people can run their own Pomodoros on the same channel
+	for _, user := range []string{"Alice", "Bob"} {
+		key := KeyPerUserInChannel.Key("Focus", user)
+		ExpectedActual(t, true, cpm.CreateIfEmpty(key, settings, nil, nil, onEnd, testInfo{UserID: user, ChannelID: "Focus"}), "creating for "+user)
+	}
+	ExpectedActual(t, false, cpm.CreateIfEmpty(KeyPerUserInChannel.Key("Focus", "Alice"), settings, nil, nil, onEnd, testInfo{UserID: "Alice", ChannelID: "Focus"}), "creating twice")
+	ExpectedActual(t, 2, cpm.Count(), "count of independent Pomodoros")
+
+	key, ok := cpm.Lookup(KeyPerUserInChannel, "Focus", "Bob", joinedOn("Focus", "Bob"))
+	ExpectedActual(t, true, ok, "looking up own Pomodoro")
+	ExpectedActual(t, KeyPerUserInChannel.Key("Focus", "Bob"), key, "own Pomodoro key")
+
+	// Someone without their own Pomodoro finds the one they joined
+	cpm.Update(KeyPerUserInChannel.Key("Focus", "Alice"), func(info *testInfo) bool {
+		info.Participants = append(info.Participants, "Carol")
+		return true
+	})
+	key, ok = cpm.Lookup(KeyPerUserInChannel, "Focus", "Carol", joinedOn("Focus", "Carol"))
+	ExpectedActual(t, true, ok, "looking up joined Pomodoro")
+	ExpectedActual(t, KeyPerUserInChannel.Key("Focus", "Alice"), key, "joined Pomodoro key")
+
+	key, ok = cpm.Lookup(KeyPerUserInChannel, "Focus", "Dave", joinedOn("Focus", "Dave"))
+	ExpectedActual(t, false, ok, "looking up with no Pomodoro")
+	ExpectedActual(t, KeyPerUserInChannel.Key("Focus", "Dave"), key, "key to start with")
+	_, ok = cpm.Lookup(KeyPerUserInChannel, "Elsewhere", "Carol", joinedOn("Elsewhere", "Carol"))
+	ExpectedActual(t, false, ok, "looking up joined Pomodoro on another channel")
+
+	// Per user Pomodoros are found from any channel
+	cpm.CreateIfEmpty(KeyPerUser.Key("Desk", "Erin"), settings, nil, nil, onEnd, testInfo{UserID: "Erin", ChannelID: "Desk"})
+	_, ok = cpm.Lookup(KeyPerUser, "Elsewhere", "Erin", joinedOn("Elsewhere", "Erin"))
+	ExpectedActual(t, true, ok, "looking up per user Pomodoro from another channel")
+
+	for _, status := range cpm.Statuses() {
+		key, ok := cpm.Find(func(s Status[testInfo]) bool { return s.Info.UserID == status.Info.UserID })
+		ExpectedActual(t, true, ok, "finding "+status.Info.UserID)
+		cpm.RemoveIfExists(key, OutcomeCancelled, "")
+	}
+	ExpectedActual(t, 0, cpm.Count(), "count after removing all")
+}
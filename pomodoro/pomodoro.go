@@ -1,147 +1,817 @@
-// Package pomodoro contains functionality for timing work tasks and calling a user-supplied callback on end or cancel.
-// This is currently Discord-specific, due to the "NotifyInfo" struct being strongly typed, rather than an "any".
-// I prefer this for now, until other concrete use cases have been decided upon.
+// Package pomodoro contains functionality for timing work tasks and breaks, and calling user-supplied callbacks as the
+// cycle progresses and on end or cancel.
+// Pomodoros are generic over the payload passed to those callbacks, so the engine isn't tied to any one chat service.
 package pomodoro
 
 import (
+	"cmp"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// lateAfter is how long after it was due a Pomodoro can be woken before it counts as late, eg because the host was
+// asleep. A late phase change or completion is marked as Delayed, and a late milestone is skipped since it's no longer
+// true.
+const lateAfter = time.Minute
+
 // Pomodoro represents a single Pomodoro instance, which can be started and stopped.
-// There were a few options I considered when designing this:
-//
-// • A sync.Mutex on each Pomodoro, which is locked and unlocked internally to maintain state.
 //
-// • Use channels to notify the Pomodoro of events, which handles the state management on its own goroutine
+// A Pomodoro has no goroutine or lock of its own. Its state is only ever changed on the goroutine of the Scheduler
+// running it, which its methods hand their work to, so it's safe to use from any goroutine without the risk of locking
+// issues as the code is expanded upon. Sharing that goroutine lets a single Scheduler run far more Pomodoros than one
+// goroutine each would allow.
+type Pomodoro[T any] struct {
+	settings    Settings // The durations and round count for this Pomodoro's cycle
+	onPhaseEnd  PhaseCallback[T]
+	onMilestone MilestoneCallback[T]
+	onWorkEnd   TaskCallback[T]
+	info        T                // The payload passed to the callbacks
+	started     time.Time        // When the Pomodoro was created
+	sched       *Scheduler       // Runs the Pomodoro, and owns everything below
+	onEvent     func(e Event[T]) // Publishes the Pomodoro's events, if it's in a ChannelPomMap
+
+	st    runState    // The state of the Pomodoro while it's running
+	ended atomic.Bool // Whether the Pomodoro has ended. Only set on the scheduler's goroutine, but read anywhere.
+	wake  wakeEntry   // The Pomodoro's place in the scheduler's queue
+}
+
+// runState is the state of a running Pomodoro. It is only ever accessed from its Scheduler's goroutine.
+type runState struct {
+	phase        Phase
+	round        int
+	phaseStarted time.Time     // When the current phase started
+	duration     time.Duration // The planned length of the current phase, including any extensions
+	extended     time.Duration // How much the current phase has been extended by
+	deadline     time.Time     // When the current phase ends, if not paused
+	paused       bool          // Whether the timer is currently paused
+	pausedAt     time.Time     // When the timer was paused
+	remaining    time.Duration // The time left in the current phase when it was paused
+	milestone    int           // The number of the current phase's milestones that have been reached
+}
+
+// startPhase moves the state to the start of the given phase.
+func (st *runState) startPhase(phase Phase, round int, start time.Time, duration time.Duration) {
+	st.phase, st.round = phase, round
+	st.phaseStarted = start
+	st.duration = duration
+	st.extended = 0
+	st.deadline = start.Add(duration)
+	st.milestone = 0
+}
+
+// remainingAt returns the time left in the current phase at the given time.
+func (st *runState) remainingAt(now time.Time) time.Duration {
+	if st.paused {
+		return st.remaining
+	}
+	return st.deadline.Sub(now)
+}
+
+// Phase is a single stage of the Pomodoro cycle.
+type Phase int
+
+const (
+	PhaseWork       Phase = iota // Focused work
+	PhaseShortBreak              // A short break between work rounds
+	PhaseLongBreak               // A long break after a full set of work rounds
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseWork:
+		return "work"
+	case PhaseShortBreak:
+		return "short break"
+	case PhaseLongBreak:
+		return "long break"
+	}
+	return "unknown"
+}
+
+// Settings describes the durations used by a Pomodoro cycle.
 //
-// I chose the channel option.  This was to avoid the risks of issues related to locking if the code is
-// expanded upon(user error), as well as generally to make it more idiomatic Go.
-type Pomodoro struct {
-	workDuration time.Duration // The duration for a regular Pomodoro work cycle
-	onWorkEnd    TaskCallback
-	notifyInfo   NotifyInfo
+// The cycle is: work -> short break -> work ... -> long break, where the long break follows every LongBreakInterval
+// work rounds. The Pomodoro ends once the long break is over. A zero-length break is skipped entirely, so a Settings
+// with only Work set is a single work timer.
+type Settings struct {
+	Work              time.Duration // The duration of each work round
+	ShortBreak        time.Duration // The duration of the break between work rounds
+	LongBreak         time.Duration // The duration of the break after the final work round
+	LongBreakInterval int           // The number of work rounds in a set. Values less than 1 are treated as 1.
+	Milestones        []Milestone   // The points during each work round at which the MilestoneCallback is called
+	MaxPause          time.Duration // How long the Pomodoro may stay paused before it ends with OutcomeTimeout. 0 is forever.
+}
+
+// Milestone is a point during a work round, such as halfway or 5 minutes left, that the MilestoneCallback is called at.
+// Milestones that would be reached at or before the start of a work round are ignored for that round.
+type Milestone struct {
+	Remaining time.Duration // Reached when this much time is left in the work round
+	Fraction  float64       // Or if Remaining is zero, when this fraction of the work round is left, eg 0.5 for halfway
+}
+
+// remainingIn returns how much time is left in a phase of the given duration when the milestone is reached.
+func (m Milestone) remainingIn(duration time.Duration) time.Duration {
+	if m.Remaining > 0 {
+		return m.Remaining
+	}
+	return time.Duration(float64(duration) * m.Fraction)
+}
+
+// milestoneAt is a Milestone along with how much time is left in the current phase when it is reached.
+type milestoneAt struct {
+	Milestone
+	at time.Duration
+}
 
-	cancelChan chan struct{} // A channel to interrupt our wait if this Pomodoro is cancelled first
-	cancel     sync.Once     // To ensure we only close the cancelChan once
+// DefaultSettings are the classic Pomodoro Technique durations.
+var DefaultSettings = Settings{
+	Work:              time.Minute * 25,
+	ShortBreak:        time.Minute * 5,
+	LongBreak:         time.Minute * 15,
+	LongBreakInterval: 4,
 }
 
+// rounds returns the number of work rounds in the set.
+func (s Settings) rounds() int {
+	if s.LongBreakInterval < 1 {
+		return 1
+	}
+	return s.LongBreakInterval
+}
+
+// Duration returns the duration of the given phase.
+func (s Settings) Duration(p Phase) time.Duration {
+	switch p {
+	case PhaseShortBreak:
+		return s.ShortBreak
+	case PhaseLongBreak:
+		return s.LongBreak
+	}
+	return s.Work
+}
+
+// milestones returns the milestones of a phase with the given duration, in the order they are reached.
+func (s Settings) milestones(phase Phase, duration time.Duration) []milestoneAt {
+	if phase != PhaseWork {
+		return nil
+	}
+
+	var ms []milestoneAt
+	for _, m := range s.Milestones {
+		if at := m.remainingIn(duration); at > 0 && at < duration {
+			ms = append(ms, milestoneAt{m, at})
+		}
+	}
+	slices.SortStableFunc(ms, func(a, b milestoneAt) int {
+		return cmp.Compare(b.at, a.at)
+	})
+	return ms
+}
+
+// next returns the phase that follows the given phase in the given round, as well as the round it belongs to.
+// Returns false if the cycle is complete.
+func (s Settings) next(phase Phase, round int) (Phase, int, bool) {
+	switch phase {
+	case PhaseWork:
+		if round < s.rounds() {
+			if s.ShortBreak > 0 {
+				return PhaseShortBreak, round, true
+			}
+			return PhaseWork, round + 1, true
+		}
+		if s.LongBreak > 0 {
+			return PhaseLongBreak, round, true
+		}
+	case PhaseShortBreak:
+		return PhaseWork, round + 1, true
+	}
+	return PhaseWork, round, false
+}
+
+// Transition describes the move from one phase of the cycle to the next.
+type Transition struct {
+	Ended  Phase // The phase that just ended
+	Next   Phase // The phase that is starting now
+	Round  int   // The work round the next phase belongs to, starting at 1
+	Rounds int   // The total number of work rounds in the set
+
+	Started  time.Time     // When the ended phase started
+	Duration time.Duration // How long the ended phase ran for, not counting time spent paused
+	Extended time.Duration // How much the ended phase was extended by, which is included in Duration
+	Delayed  bool          // Whether the phase ended while the Pomodoro wasn't running, eg during a restart or while the host slept
+}
+
+// PhaseCallback is the type of function that will be called when a phase ends and the next one begins. It is not called
+// when the final phase ends - the TaskCallback is called instead. These may be called in a separate goroutine, and thus
+// should be made goroutine-safe.
+type PhaseCallback[T any] func(info T, t Transition)
+
+// MilestoneCallback is the type of function that will be called when a Milestone is reached during a work round, along
+// with the Status at that point. These may be called in a separate goroutine, and thus should be made goroutine-safe.
+type MilestoneCallback[T any] func(info T, m Milestone, status Status[T])
+
 // TaskCallback is the type of function that will be called upon Pomodoro task completion.  These may be called in a separate
 // goroutine, and thus should be made goroutine-safe.
 //
-// It receives the NotifyInfo and a boolean to tell the receiver whether the task completed (true), or was cancelled (false).
-type TaskCallback func(info NotifyInfo, completed bool)
+// It receives the Pomodoro's payload and a Result to tell the receiver how the task ended, and how far through its final
+// phase it got.
+type TaskCallback[T any] func(info T, result Result)
+
+// Outcome is the way a Pomodoro ended.
+type Outcome int
+
+const (
+	OutcomeCompleted      Outcome = iota // The whole cycle completed
+	OutcomeCancelled                     // Cancelled by one of its participants
+	OutcomeAdminCancelled                // Cancelled by someone who wasn't taking part, eg a moderator
+	OutcomeSuperseded                    // Replaced by a new Pomodoro on the same channel
+	OutcomeTimeout                       // Left paused for longer than Settings.MaxPause
+	OutcomeRestart                       // Couldn't be carried on after a restart
+)
 
-// NotifyInfo contains the necessary information to notify the creating user upon ending the Pomodoro.
-type NotifyInfo struct {
-	Title     string // The title of the work task
-	UserID    string // The UserID to notify
-	GuildID   string // The Guild (Discord server) that the user created the Pomodoro on
-	ChannelID string // The Channel to notify with the state of the Pomodoro
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeCompleted:
+		return "completed"
+	case OutcomeCancelled:
+		return "cancelled"
+	case OutcomeAdminCancelled:
+		return "admin_cancelled"
+	case OutcomeSuperseded:
+		return "superseded"
+	case OutcomeTimeout:
+		return "timeout"
+	case OutcomeRestart:
+		return "restart"
+	default:
+		return "unknown"
+	}
+}
+
+// Result describes how a Pomodoro ended, and the phase it was in at the time.
+type Result struct {
+	Outcome      Outcome       // How the Pomodoro ended
+	Reason       string        // The reason given for cancelling it, if any
+	Phase        Phase         // The phase the Pomodoro ended in
+	Round        int           // The work round that phase belongs to, starting at 1
+	PhaseStarted time.Time     // When that phase started
+	Planned      time.Duration // The planned length of that phase, including any extensions
+	Extended     time.Duration // How much that phase was extended by, which is included in Planned
+	Elapsed      time.Duration // How much of that phase had elapsed, not counting time spent paused
+	Delayed      bool          // Whether it completed while the Pomodoro wasn't running, eg while the host slept
 }
 
-// NewPomodoro creates a new Pomodoro and starts it, similar to time.NewTimer. "Start" functionality
+// Status is a snapshot of the state of a running Pomodoro.
+type Status[T any] struct {
+	Info      T             // The Pomodoro's payload, as last updated
+	Started   time.Time     // When the Pomodoro was started
+	Phase     Phase         // The current phase of the cycle
+	Round     int           // The work round the current phase belongs to, starting at 1
+	Rounds    int           // The total number of work rounds in the set
+	Paused    bool          // Whether the Pomodoro is paused
+	Deadline  time.Time     // When the current phase will end. If paused, this is when it would end if resumed now.
+	Remaining time.Duration // The time left in the current phase
+	Duration  time.Duration // The full length of the current phase, including any extensions
+}
+
+// NewPomodoro creates a new Pomodoro with a single work round and starts it, similar to time.NewTimer. "Start" functionality
 // is intentionally omitted to prevent double-starting.
 //
 // onWorkEnd is called after the Pomodoro has been completed or cancelled.
-func NewPomodoro(workDuration time.Duration, onWorkEnd TaskCallback, notify NotifyInfo) *Pomodoro {
-	pom := &Pomodoro{
-		workDuration,
-		onWorkEnd,
-		notify,
-		make(chan struct{}),
-		sync.Once{},
-	}
+func NewPomodoro[T any](workDuration time.Duration, onWorkEnd TaskCallback[T], info T) *Pomodoro[T] {
+	return NewPomodoroCycle(Settings{Work: workDuration}, nil, nil, onWorkEnd, info)
+}
+
+// NewPomodoroCycle creates a new Pomodoro that runs through the full cycle described by settings, and starts it.
+//
+// onPhaseEnd is called on each transition between phases, and onMilestone when each of the settings' milestones is
+// reached. Either may be nil. onWorkEnd is called after the final phase has been completed, or the Pomodoro is cancelled.
+func NewPomodoroCycle[T any](settings Settings, onPhaseEnd PhaseCallback[T], onMilestone MilestoneCallback[T], onWorkEnd TaskCallback[T], info T) *Pomodoro[T] {
+	return startPomodoro(defaultScheduler(), settings, onPhaseEnd, onMilestone, onWorkEnd, info)
+}
+
+// startPomodoro creates a new Pomodoro cycle run by the scheduler, and starts it. See NewPomodoroCycle.
+func startPomodoro[T any](sched *Scheduler, settings Settings, onPhaseEnd PhaseCallback[T], onMilestone MilestoneCallback[T], onWorkEnd TaskCallback[T], info T) *Pomodoro[T] {
+	pom := newPomodoro(sched, settings, onPhaseEnd, onMilestone, onWorkEnd, info, sched.clock.Now())
+	pom.startCycle()
+
+	return pom
+}
 
-	go pom.performPom()
+// newPomodoro creates a Pomodoro run by the scheduler without starting it.
+func newPomodoro[T any](sched *Scheduler, settings Settings, onPhaseEnd PhaseCallback[T], onMilestone MilestoneCallback[T], onWorkEnd TaskCallback[T], info T, started time.Time) *Pomodoro[T] {
+	pom := &Pomodoro[T]{
+		settings:    settings,
+		onPhaseEnd:  onPhaseEnd,
+		onMilestone: onMilestone,
+		onWorkEnd:   onWorkEnd,
+		info:        info,
+		started:     started,
+		sched:       sched,
+	}
+	pom.wake = wakeEntry{index: -1, wake: pom.onWake}
 
 	return pom
 }
 
-// Cancel is used to cancel a current work cycle. This uses "sync.Once" so we prevent a panic if, for whatever
-// reason, the caller is able to call Cancel more than once.
+// startCycle starts the Pomodoro from the beginning of its cycle.
+func (pom *Pomodoro[T]) startCycle() {
+	var st runState
+	st.startPhase(PhaseWork, 1, pom.started, pom.settings.Work)
+	pom.start(st, false)
+}
+
+// start runs the Pomodoro from the given state until it is complete or cancelled. restored is whether the state came
+// from a Snapshot.
+func (pom *Pomodoro[T]) start(st runState, restored bool) {
+	pom.sched.do(func() {
+		pom.st = st
+		pom.schedule()
+		pom.publish(Event[T]{Type: EventStarted, Restored: restored}, pom.sched.clock.Now())
+	})
+}
+
+// Completed returns whether the whole cycle completed, rather than ending early.
+func (r Result) Completed() bool {
+	return r.Outcome == OutcomeCompleted
+}
+
+// Cancel is used to cancel a current work cycle, with the outcome and optional reason that are passed on to the
+// TaskCallback.
+//
+// This method is goroutine-safe, and will cancel a Pomodoro only once (multiple calls are OK, but only the first
+// outcome and reason are kept).
+func (pom *Pomodoro[T]) Cancel(outcome Outcome, reason string) {
+	pom.control(func(st *runState) bool {
+		pom.end(outcome, reason, pom.sched.clock.Now(), false)
+		return true
+	})
+}
+
+// Pause stops the timer of the current phase, preserving the remaining time until Resume is called.
+// Returns false if the Pomodoro was already paused or has ended.
 //
-// This method is goroutine-safe, and will cancel a Pomodoro only once (multiple calls are OK).
-func (pom *Pomodoro) Cancel() {
-	pom.cancel.Do(func() {
-		close(pom.cancelChan)
+// This method is goroutine-safe.
+func (pom *Pomodoro[T]) Pause() bool {
+	return pom.control(func(st *runState) bool {
+		if st.paused {
+			return false
+		}
+		now := pom.sched.clock.Now()
+		st.remaining = st.remainingAt(now)
+		st.paused, st.pausedAt = true, now
+		pom.publish(Event[T]{Type: EventPaused}, now)
+		return true
+	})
+}
+
+// Resume restarts the timer of a paused Pomodoro with the time that was remaining when it was paused.
+// Returns false if the Pomodoro was not paused or has ended.
+//
+// This method is goroutine-safe.
+func (pom *Pomodoro[T]) Resume() bool {
+	return pom.control(func(st *runState) bool {
+		if !st.paused {
+			return false
+		}
+		now := pom.sched.clock.Now()
+		st.paused = false
+		st.deadline = now.Add(st.remaining)
+		pom.publish(Event[T]{Type: EventResumed}, now)
+		return true
+	})
+}
+
+// Extend adds d to the current phase, pushing back its deadline. If paused, the time remaining is extended instead.
+// The phase keeps its original start time. Returns false if d isn't positive, or the Pomodoro has ended.
+//
+// This method is goroutine-safe.
+func (pom *Pomodoro[T]) Extend(d time.Duration) bool {
+	if d <= 0 {
+		return false
+	}
+
+	return pom.control(func(st *runState) bool {
+		st.duration += d
+		st.extended += d
+		if st.paused {
+			st.remaining += d
+		} else {
+			st.deadline = st.deadline.Add(d)
+		}
+		// Milestones that have already been reached may now be ahead of us again
+		now := pom.sched.clock.Now()
+		pom.skipMilestones(st, now)
+		pom.publish(Event[T]{Type: EventExtended, Extension: d}, now)
+		return true
+	})
+}
+
+// Update runs update on the Pomodoro's payload, so the changes are included in its Status and all later callbacks. The
+// payload may already have been passed to callbacks, so update shouldn't modify anything it shares with earlier copies,
+// such as the contents of a slice. Returns the result of update, or false if the Pomodoro has ended.
+//
+// This method is goroutine-safe.
+func (pom *Pomodoro[T]) Update(update func(info *T) bool) bool {
+	return pom.control(func(st *runState) bool {
+		return update(&pom.info)
+	})
+}
+
+// Status returns a snapshot of the Pomodoro's current state. Returns false if the Pomodoro has ended.
+//
+// This method is goroutine-safe.
+func (pom *Pomodoro[T]) Status() (Status[T], bool) {
+	var status Status[T]
+	ok := pom.control(func(st *runState) bool {
+		status = pom.status(st, pom.sched.clock.Now())
+		return true
 	})
+
+	return status, ok
+}
+
+// status describes the Pomodoro in the given state at the given time.
+func (pom *Pomodoro[T]) status(st *runState, now time.Time) Status[T] {
+	status := Status[T]{
+		Info:     pom.info,
+		Started:  pom.started,
+		Phase:    st.phase,
+		Round:    st.round,
+		Rounds:   pom.settings.rounds(),
+		Paused:   st.paused,
+		Duration: st.duration,
+	}
+	status.Remaining = st.remainingAt(now)
+	status.Deadline = st.deadline
+	if st.paused {
+		status.Deadline = now.Add(status.Remaining)
+	}
+	return status
+}
+
+// nextMilestone returns the next milestone to be reached in the current phase, or false if there are none left or no one
+// is listening for them.
+func (pom *Pomodoro[T]) nextMilestone(st *runState) (milestoneAt, bool) {
+	if pom.onMilestone == nil && pom.onEvent == nil {
+		return milestoneAt{}, false
+	}
+
+	ms := pom.settings.milestones(st.phase, st.duration)
+	if st.milestone >= len(ms) {
+		return milestoneAt{}, false
+	}
+	return ms[st.milestone], true
+}
+
+// skipMilestones marks the milestones of the current phase that would have been reached by the given time as reached,
+// and the rest as not, eg after the phase has been extended or restored.
+func (pom *Pomodoro[T]) skipMilestones(st *runState, now time.Time) {
+	remaining := st.remainingAt(now)
+	st.milestone = 0
+	for _, m := range pom.settings.milestones(st.phase, st.duration) {
+		if m.at < remaining {
+			break
+		}
+		st.milestone++
+	}
+}
+
+// control runs the op on the scheduler's goroutine, returning its result, and reschedules the Pomodoro for any changes
+// the op made. Returns false without running the op if the Pomodoro has already ended.
+func (pom *Pomodoro[T]) control(op func(st *runState) bool) bool {
+	var result bool
+	pom.sched.do(func() {
+		if pom.ended.Load() {
+			return
+		}
+		result = op(&pom.st)
+		pom.schedule()
+	})
+
+	return result
+}
+
+// schedule sets when the scheduler should next wake the Pomodoro, which is when its next milestone is reached or its
+// phase ends. Paused Pomodoros are only woken if their pause runs out. It must only be called on the scheduler's
+// goroutine.
+func (pom *Pomodoro[T]) schedule() {
+	st := &pom.st
+	switch {
+	case pom.ended.Load():
+		pom.sched.unwake(&pom.wake)
+	case st.paused && pom.settings.MaxPause > 0:
+		pom.sched.wakeAt(&pom.wake, st.pausedAt.Add(pom.settings.MaxPause))
+	case st.paused:
+		pom.sched.unwake(&pom.wake)
+	default:
+		// We wake up for the next milestone first, if there is one, since they always come before the deadline
+		wakeAt := st.deadline
+		if milestone, ok := pom.nextMilestone(st); ok {
+			wakeAt = st.deadline.Add(-milestone.at)
+		}
+		pom.sched.wakeAt(&pom.wake, wakeAt)
+	}
+}
+
+// onWake is called on the scheduler's goroutine when the Pomodoro's pause has run out, or it reaches its next
+// milestone or the end of its phase.
+func (pom *Pomodoro[T]) onWake(now time.Time) {
+	st := &pom.st
+	// Callbacks are given the payload as it is now, rather than when they're run
+	info := pom.info
+	if st.paused {
+		pom.end(OutcomeTimeout, "", now, false)
+		return
+	}
+	late := now.Sub(pom.wake.at) > lateAfter
+	if milestone, ok := pom.nextMilestone(st); ok {
+		st.milestone++
+		if !late {
+			if pom.onMilestone != nil {
+				status := pom.status(st, now)
+				pom.sched.callback(func() { pom.onMilestone(info, milestone.Milestone, status) })
+			}
+			pom.publish(Event[T]{Type: EventMilestone, Milestone: milestone.Milestone}, now)
+		}
+		pom.schedule()
+		return
+	}
+
+	nextPhase, nextRound, ok := pom.settings.next(st.phase, st.round)
+	if !ok {
+		pom.end(OutcomeCompleted, "", now, late)
+		return
+	}
+
+	t := pom.transition(st, nextPhase, nextRound)
+	t.Delayed = late
+	if pom.onPhaseEnd != nil {
+		pom.sched.callback(func() { pom.onPhaseEnd(info, t) })
+	}
+	// The next phase starts from the previous deadline rather than now, so we don't drift over a long cycle
+	st.startPhase(nextPhase, nextRound, st.deadline, pom.settings.Duration(nextPhase))
+	pom.publish(Event[T]{Type: EventPhaseChanged, Transition: t}, now)
+	pom.schedule()
+}
+
+// end stops the Pomodoro with the given outcome, and calls onWorkEnd. The result is marked as Delayed if it's late. It
+// must only be called on the scheduler's goroutine.
+func (pom *Pomodoro[T]) end(outcome Outcome, reason string, now time.Time, late bool) {
+	pom.ended.Store(true)
+	pom.schedule()
+
+	info, result := pom.info, pom.st.result(outcome, reason, now)
+	result.Delayed = late
+	pom.sched.callback(func() { pom.onWorkEnd(info, result) })
+
+	eventType := EventCancelled
+	if result.Completed() {
+		eventType = EventCompleted
+	}
+	pom.publish(Event[T]{Type: eventType, Result: result}, now)
+}
+
+// publish sends the event to the Pomodoro's map if it's in one, filling in what's common to all events. It must only be
+// called on the scheduler's goroutine.
+func (pom *Pomodoro[T]) publish(e Event[T], now time.Time) {
+	if pom.onEvent == nil {
+		return
+	}
+
+	e.Info, e.Time = pom.info, now
+	if !pom.ended.Load() {
+		e.Status = pom.status(&pom.st, now)
+	}
+	pom.onEvent(e)
 }
 
-func (pom *Pomodoro) performPom() {
-	workTimer := time.NewTimer(pom.workDuration)
+// transition describes the move from the current phase to the given one.
+func (pom *Pomodoro[T]) transition(st *runState, next Phase, round int) Transition {
+	return Transition{
+		Ended:    st.phase,
+		Next:     next,
+		Round:    round,
+		Rounds:   pom.settings.rounds(),
+		Started:  st.phaseStarted,
+		Duration: st.duration,
+		Extended: st.extended,
+	}
+}
+
+// result describes the Pomodoro ending in its current phase at the given time, with the given outcome.
+func (st *runState) result(outcome Outcome, reason string, now time.Time) Result {
+	elapsed := st.duration
+	if outcome != OutcomeCompleted {
+		elapsed -= st.remainingAt(now)
+	}
 
-	select {
-	case <-workTimer.C:
-		go pom.onWorkEnd(pom.notifyInfo, true)
-	case <-pom.cancelChan:
-		workTimer.Stop()
-		go pom.onWorkEnd(pom.notifyInfo, false)
+	return Result{
+		Outcome:      outcome,
+		Reason:       reason,
+		Phase:        st.phase,
+		Round:        st.round,
+		PhaseStarted: st.phaseStarted,
+		Planned:      st.duration,
+		Extended:     st.extended,
+		Elapsed:      elapsed,
 	}
 }
 
-// ChannelPomMap is a map-like structure that has goroutine-safe operations to create Pomodoros on individual channels.
-type ChannelPomMap struct {
-	mutex        sync.Mutex
-	channelToPom map[string]*Pomodoro
+// ChannelPomMap is a map-like structure that has goroutine-safe operations to create Pomodoros on individual channels,
+// or for individual users, depending on the KeyMode used to make their Keys.
+type ChannelPomMap[T any] struct {
+	mutex    sync.Mutex
+	keyToPom map[Key]*Pomodoro[T]
+	sched    *Scheduler // Runs every Pomodoro in the map
+	running  int        // How many of the map's Pomodoros have started and not yet ended. Only used on the scheduler's goroutine.
+
+	subsMutex sync.Mutex
+	subs      []*subscriber[T] // Everything subscribed to the map's events
+}
+
+// NewChannelPomMap creates a ChannelPomMap and prepares it to be used. Its Pomodoros are run by a Scheduler shared with
+// all other maps made this way.
+func NewChannelPomMap[T any]() ChannelPomMap[T] {
+	return NewChannelPomMapWithScheduler[T](defaultScheduler())
 }
 
-// NewChannelPomMap creates a ChannelPomMap and prepares it to be used.
-func NewChannelPomMap() ChannelPomMap {
-	return ChannelPomMap{channelToPom: make(map[string]*Pomodoro)}
+// NewChannelPomMapWithScheduler creates a ChannelPomMap whose Pomodoros are run by the scheduler, eg one with a FakeClock
+// in tests.
+func NewChannelPomMapWithScheduler[T any](sched *Scheduler) ChannelPomMap[T] {
+	return ChannelPomMap[T]{keyToPom: make(map[Key]*Pomodoro[T]), sched: sched}
 }
 
-// CreateIfEmpty will create and start a Pomodoro on the given channel if one does not already exist.
-// The Pomodoro will be removed from the map when its work is complete, or it is cancelled.
+// CreateIfEmpty will create and start a Pomodoro cycle with the given key if one does not already exist.
+// The Pomodoro will be removed from the map when its final phase is complete, or it is cancelled. Any of the callbacks
+// may be nil, eg when using Subscribe instead.
 //
 // This method is goroutine-safe.
-func (m *ChannelPomMap) CreateIfEmpty(duration time.Duration, onWorkEnd TaskCallback, notify NotifyInfo) bool {
+func (m *ChannelPomMap[T]) CreateIfEmpty(key Key, settings Settings, onPhaseEnd PhaseCallback[T], onMilestone MilestoneCallback[T], onWorkEnd TaskCallback[T], info T) bool {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	wasCreated := false
-	if _, exists := m.channelToPom[notify.ChannelID]; !exists {
-		// Ensure we remove the Pomodoro from the map when it completes
-		doneInMap := func(notif NotifyInfo, completed bool) {
-			// Note that this call is done so we use the mutex. The cancellation will never trigger "onWorkEnd", since the "performPom"
-			// goroutine will already be complete by this point.
-			m.RemoveIfExists(notif.ChannelID)
-			onWorkEnd(notif, completed)
-		}
-
-		m.channelToPom[notify.ChannelID] = NewPomodoro(duration, doneInMap, notify)
+	if m.runningPom(key) == nil {
+		var pom *Pomodoro[T]
+		pom = newPomodoro(m.sched, settings, onPhaseEnd, onMilestone, m.doneInMap(onWorkEnd, key, &pom), info, m.sched.clock.Now())
+		pom.onEvent = m.publisher(key)
+		pom.startCycle()
+		m.keyToPom[key] = pom
 		wasCreated = true
 	}
 
 	return wasCreated
 }
 
-// RemoveIfExists will stop and remove a Pomodoro from the given channel if one exists.  Note that this will perform cancellation of
-// the Pomodoro if it is running and call the onWorkEnded callback.
+// doneInMap wraps onWorkEnd to ensure we remove the Pomodoro from the map when it completes. The Pomodoro is passed by
+// pointer as it's only known once created, which is always done while holding the mutex. We only remove the map's
+// Pomodoro if it's still that one, since it may have been replaced under the same key by then.
+func (m *ChannelPomMap[T]) doneInMap(onWorkEnd TaskCallback[T], key Key, pom **Pomodoro[T]) TaskCallback[T] {
+	return func(info T, result Result) {
+		m.mutex.Lock()
+		if m.keyToPom[key] == *pom {
+			delete(m.keyToPom, key)
+		}
+		m.mutex.Unlock()
+
+		if onWorkEnd != nil {
+			onWorkEnd(info, result)
+		}
+	}
+}
+
+// RemoveIfExists will stop and remove the Pomodoro with the given key if one exists.  Note that this will perform cancellation of
+// the Pomodoro if it is running and call the onWorkEnded callback with the given outcome and reason.
 //
 // It returns a boolean representing whether the Pomodoro was removed.
 //
 // This method is goroutine-safe.
-func (m *ChannelPomMap) RemoveIfExists(channel string) bool {
+func (m *ChannelPomMap[T]) RemoveIfExists(key Key, outcome Outcome, reason string) bool {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	wasRemoved := false
-	if p, exists := m.channelToPom[channel]; exists {
-		delete(m.channelToPom, channel)
-		p.Cancel()
+	if p := m.runningPom(key); p != nil {
+		delete(m.keyToPom, key)
+		p.Cancel(outcome, reason)
 		wasRemoved = true
 	}
 
 	return wasRemoved
 }
 
+// Pause pauses the Pomodoro with the given key, returning false if there is none or it is already paused.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Pause(key Key) bool {
+	if p := m.get(key); p != nil {
+		return p.Pause()
+	}
+	return false
+}
+
+// Resume resumes the paused Pomodoro with the given key, returning false if there is none or it is not paused.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Resume(key Key) bool {
+	if p := m.get(key); p != nil {
+		return p.Resume()
+	}
+	return false
+}
+
+// Extend adds d to the current phase of the Pomodoro with the given key, returning false if there is none or d isn't
+// positive.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Extend(key Key, d time.Duration) bool {
+	if p := m.get(key); p != nil {
+		return p.Extend(d)
+	}
+	return false
+}
+
+// Update runs update on the payload of the Pomodoro with the given key, returning its result, or false if there is none.
+// See Pomodoro.Update.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Update(key Key, update func(info *T) bool) bool {
+	if p := m.get(key); p != nil {
+		return p.Update(update)
+	}
+	return false
+}
+
+// Status returns a snapshot of the state of the Pomodoro with the given key, or false if there is none.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Status(key Key) (Status[T], bool) {
+	if p := m.get(key); p != nil {
+		return p.Status()
+	}
+	return Status[T]{}, false
+}
+
+// Statuses returns the status of all the Pomodoros currently being tracked.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Statuses() []Status[T] {
+	poms := m.all()
+	statuses := make([]Status[T], 0, len(poms))
+	for _, p := range poms {
+		if status, ok := p.Status(); ok {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// all returns a copy of all the Pomodoros currently being tracked, by key. The lock isn't held afterwards, since callers
+// shouldn't hold it while waiting on each Pomodoro.
+func (m *ChannelPomMap[T]) all() map[Key]*Pomodoro[T] {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	poms := maps.Clone(m.keyToPom)
+	maps.DeleteFunc(poms, func(_ Key, p *Pomodoro[T]) bool { return p.ended.Load() })
+	return poms
+}
+
+// get returns the Pomodoro with the given key, or nil if there is none.
+func (m *ChannelPomMap[T]) get(key Key) *Pomodoro[T] {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.runningPom(key)
+}
+
+// runningPom returns the Pomodoro with the given key, or nil if there is none. Pomodoros that have ended are treated as
+// already removed, since they're only removed once onWorkEnd is run but subscribers may hear they've ended before then.
+// The mutex must be held.
+func (m *ChannelPomMap[T]) runningPom(key Key) *Pomodoro[T] {
+	if p := m.keyToPom[key]; p != nil && !p.ended.Load() {
+		return p
+	}
+	return nil
+}
+
 // Count returns the number of Pomodoros currently being tracked.
 //
 // This method is goroutine-safe.
-func (m *ChannelPomMap) Count() int {
+func (m *ChannelPomMap[T]) Count() int {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	return len(m.channelToPom)
+	count := 0
+	for _, p := range m.keyToPom {
+		if !p.ended.Load() {
+			count++
+		}
+	}
+	return count
 }
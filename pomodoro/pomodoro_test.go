@@ -2,6 +2,8 @@ package pomodoro
 
 import (
 	"fmt"
+	"reflect"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -9,87 +11,332 @@ import (
 	. "github.com/seanpfeifer/rigging/assert"
 )
 
-// timeTolerance is the amount of time difference we will tolerate in a test before failing.
-// The required value can vary between machines and CI environments, so this may need tweaking,
-// but we should keep an eye on it so it stays somewhat reasonable per test.
-const timeTolerance = time.Millisecond * 8
+// testStart is when the FakeClocks used in tests start.
+var testStart = time.Date(2024, time.March, 14, 9, 0, 0, 0, time.UTC)
+
+// testWorkers is how many callbacks the Schedulers used in tests run at once, which needs to be enough that callbacks
+// waiting on a test don't hold up others.
+const testWorkers = 4
+
+// newTestScheduler creates a Scheduler that's stopped once the test is done.
+func newTestScheduler(t testing.TB, clock Clock, workers int) *Scheduler {
+	sched := NewScheduler(clock, workers)
+	t.Cleanup(sched.Stop)
+	return sched
+}
+
+// testInfo is the payload of the Pomodoros used in tests.
+type testInfo struct {
+	Title        string
+	UserID       string
+	ChannelID    string
+	Participants []string
+}
 
 func TestPomodoro(t *testing.T) {
-	const testDuration = time.Millisecond * 42
+	const testDuration = time.Minute * 25
+	clock := NewFakeClock(testStart)
 	c := make(chan bool)
-	testFunc := func(_ NotifyInfo, completed bool) {
-		c <- completed
+	testFunc := func(_ testInfo, result Result) {
+		c <- result.Completed()
 	}
 
-	startTime := time.Now()
-	// Intentionally not using the returned Pomodoro - no need for it
-	NewPomodoro(testDuration, testFunc, NotifyInfo{})
+	pom := startPomodoro(newTestScheduler(t, clock, testWorkers), Settings{Work: testDuration}, nil, nil, testFunc, testInfo{})
+	clock.Advance(testDuration - time.Second)
+	status, ok := pom.Status()
+	ExpectedActual(t, true, ok, "Pomodoro running before its deadline")
+	ExpectedActual(t, time.Second, status.Remaining, "remaining time before the deadline")
+
+	clock.Advance(time.Second)
 	completed := <-c
 	ExpectedActual(t, true, completed, "Pomodoro completion")
+}
 
-	endDuration := time.Since(startTime)
+func TestPomodoroCancel(t *testing.T) {
+	const testDuration = time.Minute * 25
+	const cancelDuration = time.Minute * 10
+	clock := NewFakeClock(testStart)
+	c := make(chan Result)
+	testFunc := func(_ testInfo, result Result) {
+		c <- result
+	}
 
-	ExpectedApprox(t, testDuration, endDuration, timeTolerance, "ending Pomorodoro on time")
+	pom := startPomodoro(newTestScheduler(t, clock, testWorkers), Settings{Work: testDuration}, nil, nil, testFunc, testInfo{})
+	clock.Advance(cancelDuration)
+	pom.Cancel(OutcomeAdminCancelled, "Fire drill")
+	pom.Cancel(OutcomeCancelled, "Ignored")
+
+	result := <-c
+	ExpectedActual(t, false, result.Completed(), "Pomodoro cancellation")
+	ExpectedActual(t, OutcomeAdminCancelled, result.Outcome, "cancelled outcome")
+	ExpectedActual(t, "Fire drill", result.Reason, "cancelled reason")
+	ExpectedActual(t, PhaseWork, result.Phase, "cancelled phase")
+	ExpectedActual(t, testStart, result.PhaseStarted, "cancelled phase start")
+	ExpectedActual(t, testDuration, result.Planned, "cancelled planned duration")
+	ExpectedActual(t, cancelDuration, result.Elapsed, "cancelled elapsed duration")
 }
 
-func TestPomodoroCancel(t *testing.T) {
-	const testDuration = time.Millisecond * 100
-	const cancelDuration = time.Millisecond * 10
+func TestPomodoroPause(t *testing.T) {
+	const testDuration = time.Minute * 40
+	const pauseAfter = time.Minute * 10
+	const pauseDuration = time.Minute * 30
+	clock := NewFakeClock(testStart)
+	c := make(chan Result)
+	testFunc := func(_ testInfo, result Result) {
+		c <- result
+	}
+
+	pom := startPomodoro(newTestScheduler(t, clock, testWorkers), Settings{Work: testDuration}, nil, nil, testFunc, testInfo{})
+	ExpectedActual(t, false, pom.Resume(), "resuming a running Pomodoro")
+
+	clock.Advance(pauseAfter)
+	ExpectedActual(t, true, pom.Pause(), "pausing a running Pomodoro")
+	ExpectedActual(t, false, pom.Pause(), "pausing a paused Pomodoro")
+
+	status, ok := pom.Status()
+	ExpectedActual(t, true, ok, "status of a paused Pomodoro")
+	ExpectedActual(t, true, status.Paused, "status paused")
+
+	// Far longer than the Pomodoro, which mustn't end while paused
+	clock.Advance(pauseDuration)
+	status, _ = pom.Status()
+	ExpectedActual(t, testDuration-pauseAfter, status.Remaining, "remaining time is kept while paused")
+	ExpectedActual(t, clock.Now().Add(testDuration-pauseAfter), status.Deadline, "deadline if resumed now")
+	ExpectedActual(t, true, pom.Resume(), "resuming a paused Pomodoro")
+
+	clock.Advance(testDuration - pauseAfter)
+	result := <-c
+	ExpectedActual(t, true, result.Completed(), "Pomodoro completion")
+	ExpectedActual(t, false, result.Delayed, "delayed completion")
+	ExpectedActual(t, testDuration, result.Elapsed, "elapsed duration doesn't count the pause")
+	ExpectedActual(t, false, pom.Pause(), "pausing an ended Pomodoro")
+	_, ok = pom.Status()
+	ExpectedActual(t, false, ok, "status of an ended Pomodoro")
+}
+
+func TestPomodoroMaxPause(t *testing.T) {
+	const maxPause = time.Hour * 4
+	clock := NewFakeClock(testStart)
+	c := make(chan Result)
+	settings := Settings{Work: time.Minute * 25, MaxPause: maxPause}
+	pom := startPomodoro(newTestScheduler(t, clock, testWorkers), settings, nil, nil, func(_ testInfo, result Result) { c <- result }, testInfo{})
+
+	clock.Advance(time.Minute * 5)
+	pom.Pause()
+	clock.Advance(maxPause - time.Second)
+	status, ok := pom.Status()
+	ExpectedActual(t, true, ok, "Pomodoro running before its pause runs out")
+	ExpectedActual(t, true, status.Paused, "status paused")
+
+	clock.Advance(time.Second)
+	result := <-c
+	ExpectedActual(t, OutcomeTimeout, result.Outcome, "outcome of a Pomodoro paused for too long")
+	ExpectedActual(t, time.Minute*5, result.Elapsed, "elapsed duration of a Pomodoro paused for too long")
+}
+
+func TestPomodoroExtend(t *testing.T) {
+	const testDuration = time.Minute * 20
+	const extension = time.Minute * 30
+	clock := NewFakeClock(testStart)
+	c := make(chan Result)
+	testFunc := func(_ testInfo, result Result) {
+		c <- result
+	}
+
+	pom := startPomodoro(newTestScheduler(t, clock, testWorkers), Settings{Work: testDuration}, nil, nil, testFunc, testInfo{})
+	ExpectedActual(t, false, pom.Extend(-extension), "extending by a negative duration")
+	ExpectedActual(t, true, pom.Extend(extension), "extending a running Pomodoro")
+
+	clock.Advance(testDuration)
+	status, _ := pom.Status()
+	ExpectedActual(t, extension, status.Remaining, "remaining time after the original deadline")
+
+	clock.Advance(extension)
+	result := <-c
+	ExpectedActual(t, true, result.Completed(), "Pomodoro completion")
+	ExpectedActual(t, testDuration+extension, result.Planned, "extended planned duration")
+	ExpectedActual(t, extension, result.Extended, "extension")
+	ExpectedActual(t, false, pom.Extend(extension), "extending an ended Pomodoro")
+}
+
+func TestPomodoroCycle(t *testing.T) {
+	settings := Settings{
+		Work:              time.Minute * 25,
+		ShortBreak:        time.Minute * 5,
+		LongBreak:         time.Minute * 15,
+		LongBreakInterval: 2,
+	}
+	clock := NewFakeClock(testStart)
+	transitions := make(chan Transition)
+	onPhase := func(_ testInfo, tr Transition) {
+		transitions <- tr
+	}
 	c := make(chan bool)
-	testFunc := func(_ NotifyInfo, completed bool) {
-		c <- completed
+	onEnd := func(_ testInfo, result Result) {
+		c <- result.Completed()
 	}
 
-	startTime := time.Now()
-	pom := NewPomodoro(testDuration, testFunc, NotifyInfo{})
-	go func() {
-		time.Sleep(cancelDuration)
-		pom.Cancel()
-	}()
+	startPomodoro(newTestScheduler(t, clock, testWorkers), settings, onPhase, nil, onEnd, testInfo{})
 
-	completed := <-c
-	ExpectedActual(t, false, completed, "Pomodoro cancellation")
-	endDuration := time.Since(startTime)
+	expected := []Transition{
+		{Ended: PhaseWork, Next: PhaseShortBreak, Round: 1, Rounds: 2, Started: testStart, Duration: settings.Work},
+		{Ended: PhaseShortBreak, Next: PhaseWork, Round: 2, Rounds: 2, Started: testStart.Add(time.Minute * 25), Duration: settings.ShortBreak},
+		{Ended: PhaseWork, Next: PhaseLongBreak, Round: 2, Rounds: 2, Started: testStart.Add(time.Minute * 30), Duration: settings.Work},
+	}
+	for i, exp := range expected {
+		// Callbacks run on their own goroutines, so we wait for each before moving on to keep them in order
+		clock.Advance(exp.Duration)
+		ExpectedActual(t, exp, <-transitions, fmt.Sprintf("transition %d", i))
+	}
+
+	clock.Advance(settings.LongBreak)
+	ExpectedActual(t, true, <-c, "Pomodoro cycle completion")
+}
+
+// Phases are timed from the previous deadline, so a long cycle finishes on time however late each timer is handled.
+func TestPomodoroCycleAllAtOnce(t *testing.T) {
+	settings := Settings{Work: time.Minute * 45, ShortBreak: time.Minute * 10, LongBreak: time.Minute * 30, LongBreakInterval: 4}
+	clock := NewFakeClock(testStart)
+	transitions := make(chan Transition, 8)
+	c := make(chan Result)
+
+	pom := startPomodoro(newTestScheduler(t, clock, testWorkers), settings, func(_ testInfo, tr Transition) { transitions <- tr }, nil, func(_ testInfo, result Result) { c <- result }, testInfo{})
+	clock.Advance(time.Hour*4 - time.Second)
+	// The status is only up to date once every phase before the long break has ended
+	for range 7 {
+		<-transitions
+	}
+	status, _ := pom.Status()
+	ExpectedActual(t, PhaseLongBreak, status.Phase, "phase just before the end of the cycle")
+	ExpectedActual(t, time.Second, status.Remaining, "remaining time just before the end of the cycle")
+
+	clock.Advance(time.Second)
+	result := <-c
+	ExpectedActual(t, true, result.Completed(), "Pomodoro cycle completion")
+	ExpectedActual(t, testStart.Add(time.Hour*4-settings.LongBreak), result.PhaseStarted, "long break start")
+}
+
+func TestPomodoroMilestones(t *testing.T) {
+	settings := Settings{
+		Work:              time.Minute * 40,
+		ShortBreak:        time.Minute * 20,
+		LongBreakInterval: 2,
+		Milestones: []Milestone{
+			{Remaining: time.Minute * 10},
+			{Fraction: 0.5},
+			{Remaining: time.Minute * 50}, // Longer than the work round, so never reached
+		},
+	}
+	type reached struct {
+		milestone Milestone
+		round     int
+		remaining time.Duration
+	}
+	clock := NewFakeClock(testStart)
+	milestones := make(chan reached, 4)
+	onMilestone := func(_ testInfo, m Milestone, status Status[testInfo]) {
+		milestones <- reached{m, status.Round, status.Remaining}
+	}
+	c := make(chan bool)
+	onEnd := func(_ testInfo, result Result) {
+		c <- result.Completed()
+	}
+
+	startPomodoro(newTestScheduler(t, clock, testWorkers), settings, nil, onMilestone, onEnd, testInfo{})
 
-	ExpectedApprox(t, cancelDuration, endDuration, timeTolerance, "cancelling Pomorodoro on time")
+	// How long to advance before each milestone. The break between rounds has none, so is skipped along with the end of
+	// the first round.
+	expected := []struct {
+		after time.Duration
+		reached
+	}{
+		{time.Minute * 20, reached{settings.Milestones[1], 1, time.Minute * 20}},
+		{time.Minute * 10, reached{settings.Milestones[0], 1, time.Minute * 10}},
+		{time.Minute * 50, reached{settings.Milestones[1], 2, time.Minute * 20}},
+		{time.Minute * 10, reached{settings.Milestones[0], 2, time.Minute * 10}},
+	}
+	for i, exp := range expected {
+		clock.Advance(exp.after)
+		ExpectedActual(t, exp.reached, <-milestones, fmt.Sprintf("milestone %d", i))
+	}
+
+	clock.Advance(time.Minute * 10)
+	ExpectedActual(t, true, <-c, "Pomodoro cycle completion")
+	ExpectedActual(t, 0, len(milestones), "extra milestones")
+}
+
+// Waking long after a deadline, eg after the host has been asleep, catches up straight away. Phase changes that are
+// late are marked as Delayed, and milestones that are late are skipped.
+func TestPomodoroLate(t *testing.T) {
+	settings := Settings{Work: time.Minute * 30, ShortBreak: time.Minute * 5, LongBreakInterval: 2, Milestones: []Milestone{{Fraction: 0.5}}}
+	clock := NewFakeClock(testStart)
+	transitions := make(chan Transition, 2)
+	milestones := make(chan Milestone, 2)
+	c := make(chan Result)
+
+	startPomodoro(newTestScheduler(t, clock, testWorkers), settings, func(_ testInfo, tr Transition) { transitions <- tr },
+		func(_ testInfo, m Milestone, _ Status[testInfo]) { milestones <- m },
+		func(_ testInfo, result Result) { c <- result }, testInfo{})
+	clock.Advance(time.Minute * 15)
+	ExpectedActual(t, settings.Milestones[0], <-milestones, "milestone on time")
+	// Being a little late is still on time
+	clock.Advance(time.Minute*15 + time.Second*30)
+	ExpectedActual(t, false, (<-transitions).Delayed, "delayed after waking a little late")
+
+	clock.Advance(time.Hour)
+	ExpectedActual(t, true, (<-transitions).Delayed, "delayed after waking long after the break ended")
+	result := <-c
+	ExpectedActual(t, true, result.Completed(), "Pomodoro cycle completion")
+	ExpectedActual(t, true, result.Delayed, "delayed completion")
+	ExpectedActual(t, testStart.Add(time.Minute*35), result.PhaseStarted, "final work round start")
+	ExpectedActual(t, 0, len(milestones), "late milestones")
+}
+
+func TestPomodoroCycleNoBreaks(t *testing.T) {
+	settings := Settings{Work: time.Minute, LongBreakInterval: 3}
+
+	phase, round, ok := settings.next(PhaseWork, 1)
+	ExpectedActual(t, true, ok, "round 1 continues")
+	ExpectedActual(t, PhaseWork, phase, "skipping zero-length short break")
+	ExpectedActual(t, 2, round, "round after skipped break")
+
+	_, _, ok = settings.next(PhaseWork, 3)
+	ExpectedActual(t, false, ok, "final round with zero-length long break ends the cycle")
 }
 
 func TestPomMapCreate(t *testing.T) {
-	cpm := NewChannelPomMap()
-	if cpm.channelToPom == nil {
+	clock := NewFakeClock(testStart)
+	cpm := NewChannelPomMapWithScheduler[testInfo](newTestScheduler(t, clock, testWorkers))
+	if cpm.keyToPom == nil {
 		t.Fatal("Expected non-nil map")
 	}
 
 	type pomTestCase struct {
 		duration      time.Duration
-		notify        NotifyInfo
+		notify        testInfo
 		shouldSucceed bool
 	}
 	cases := []pomTestCase{
-		{time.Millisecond * 42, NotifyInfo{ChannelID: "TheChannel"}, true},
-		{time.Millisecond * 42, NotifyInfo{ChannelID: "TheChannel"}, false},
-		{time.Millisecond * 42, NotifyInfo{ChannelID: "TheChannel2"}, true},
-		{time.Millisecond * 42, NotifyInfo{ChannelID: "TheChannel2"}, false},
+		{time.Minute * 25, testInfo{ChannelID: "TheChannel"}, true},
+		{time.Minute * 25, testInfo{ChannelID: "TheChannel"}, false},
+		{time.Minute * 15, testInfo{ChannelID: "TheChannel2"}, true},
+		{time.Minute * 15, testInfo{ChannelID: "TheChannel2"}, false},
 	}
 	var wg sync.WaitGroup
 	wg.Add(len(cases))
 
-	startTime := time.Now()
-
-	onFinish := func(index int, info NotifyInfo, success bool) {
+	onFinish := func(index int, info testInfo, result Result) {
 		defer wg.Done()
 
-		endDuration := time.Since(startTime)
-
-		ExpectedApprox(t, cases[index].duration, endDuration, timeTolerance, fmt.Sprintf("ending Pomorodoro %d on time", index))
-		ExpectedActual(t, true, success, fmt.Sprintf("Pomodoro %d completion success", index))
-		ExpectedActual(t, cases[index].notify, info, fmt.Sprintf("Pomodoro %d NotifyInfo", index))
+		ExpectedActual(t, cases[index].duration, result.Elapsed, fmt.Sprintf("Pomodoro %d elapsed duration", index))
+		ExpectedActual(t, true, result.Completed(), fmt.Sprintf("Pomodoro %d completion success", index))
+		ExpectedActual(t, cases[index].notify, info, fmt.Sprintf("Pomodoro %d info", index))
 	}
 
 	for i := range cases {
 		// Local variable to prevent data race issues with the onFinish() call below
 		idx := i
-		created := cpm.CreateIfEmpty(cases[i].duration, func(info NotifyInfo, completed bool) { onFinish(idx, info, completed) }, cases[i].notify)
+		created := cpm.CreateIfEmpty(Key{ChannelID: cases[i].notify.ChannelID}, Settings{Work: cases[i].duration}, nil, nil, func(info testInfo, result Result) { onFinish(idx, info, result) }, cases[i].notify)
 
 		ExpectedActual(t, cases[i].shouldSucceed, created, fmt.Sprintf("Expected creation result for case %d", i))
 		// If the task was never created, then remove it from our WaitGroup
@@ -98,12 +345,14 @@ func TestPomMapCreate(t *testing.T) {
 		}
 	}
 
+	clock.Advance(time.Minute * 25)
 	wg.Wait()
+	ExpectedActual(t, 0, cpm.Count(), "count after completion")
 }
 
 func TestPomMapRemove(t *testing.T) {
-	cpm := NewChannelPomMap()
-	if cpm.channelToPom == nil {
+	cpm := NewChannelPomMap[testInfo]()
+	if cpm.keyToPom == nil {
 		t.Fatal("Expected non-nil map")
 	}
 
@@ -112,33 +361,129 @@ func TestPomMapRemove(t *testing.T) {
 	failChan := "Doesn't Exist"
 	createdChan := "Does Exist"
 
-	ExpectedActual(t, false, cpm.RemoveIfExists(failChan), "removed unknown after initialize")
+	ExpectedActual(t, false, cpm.RemoveIfExists(Key{ChannelID: failChan}, OutcomeCancelled, ""), "removed unknown after initialize")
 
-	createdInfo := NotifyInfo{
+	createdInfo := testInfo{
 		Title:     "Some title here",
 		UserID:    "SomeID",
-		GuildID:   "SomeGuild",
 		ChannelID: createdChan,
 	}
-	onFinish := func(info NotifyInfo, completed bool) {
-		if completed {
+	onFinish := func(info testInfo, result Result) {
+		if result.Completed() {
 			t.Error("Expected cancellation, received successful completion.")
 		}
-		if info != createdInfo {
-			t.Errorf("Expected correct NotifyInfo %v. Actual %v.", createdInfo, info)
+		if !reflect.DeepEqual(info, createdInfo) {
+			t.Errorf("Expected correct info %v. Actual %v.", createdInfo, info)
 		}
 	}
 
-	if !cpm.CreateIfEmpty(time.Millisecond*300, onFinish, createdInfo) {
+	if !cpm.CreateIfEmpty(Key{ChannelID: createdChan}, Settings{Work: time.Millisecond * 300}, nil, nil, onFinish, createdInfo) {
 		t.Fatal("Failed to create valid task")
 	}
 
 	ExpectedActual(t, 1, cpm.Count(), "one count")
 	// Ensure we still don't have this failChan
-	ExpectedActual(t, false, cpm.RemoveIfExists(failChan), "removed unknown after another was added")
+	ExpectedActual(t, false, cpm.RemoveIfExists(Key{ChannelID: failChan}, OutcomeCancelled, ""), "removed unknown after another was added")
 	// Remove the one that was added
-	ExpectedActual(t, true, cpm.RemoveIfExists(createdChan), "removed created")
+	ExpectedActual(t, true, cpm.RemoveIfExists(Key{ChannelID: createdChan}, OutcomeCancelled, ""), "removed created")
 	// Ensure it was actually removed prior to here
-	ExpectedActual(t, false, cpm.RemoveIfExists(createdChan), "create should not exist")
+	ExpectedActual(t, false, cpm.RemoveIfExists(Key{ChannelID: createdChan}, OutcomeCancelled, ""), "create should not exist")
 	ExpectedActual(t, 0, cpm.Count(), "emptied count")
 }
+
+func TestPomMapSupersede(t *testing.T) {
+	cpm := NewChannelPomMap[testInfo]()
+	info := testInfo{ChannelID: "TheChannel"}
+	c := make(chan Result)
+
+	cpm.CreateIfEmpty(Key{ChannelID: info.ChannelID}, Settings{Work: time.Second}, nil, nil, func(_ testInfo, result Result) { c <- result }, info)
+	ExpectedActual(t, true, cpm.RemoveIfExists(Key{ChannelID: info.ChannelID}, OutcomeSuperseded, ""), "removing the first Pomodoro")
+	ExpectedActual(t, true, cpm.CreateIfEmpty(Key{ChannelID: info.ChannelID}, Settings{Work: time.Second}, nil, nil, func(testInfo, Result) {}, info), "creating its replacement")
+
+	// The first Pomodoro ending must not remove its replacement
+	result := <-c
+	ExpectedActual(t, OutcomeSuperseded, result.Outcome, "outcome of the replaced Pomodoro")
+	ExpectedActual(t, 1, cpm.Count(), "count after replacing")
+	cpm.RemoveIfExists(Key{ChannelID: info.ChannelID}, OutcomeCancelled, "")
+}
+
+func TestPomMapPauseResume(t *testing.T) {
+	cpm := NewChannelPomMap[testInfo]()
+	const channel = "TheChannel"
+
+	ExpectedActual(t, false, cpm.Pause(Key{ChannelID: channel}), "pausing unknown channel")
+	ExpectedActual(t, false, cpm.Resume(Key{ChannelID: channel}), "resuming unknown channel")
+
+	cpm.CreateIfEmpty(Key{ChannelID: channel}, Settings{Work: time.Second}, nil, nil, func(testInfo, Result) {}, testInfo{ChannelID: channel})
+	ExpectedActual(t, true, cpm.Pause(Key{ChannelID: channel}), "pausing channel")
+	ExpectedActual(t, true, cpm.Resume(Key{ChannelID: channel}), "resuming channel")
+	cpm.RemoveIfExists(Key{ChannelID: channel}, OutcomeCancelled, "")
+}
+
+func TestPomMapStatus(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	cpm := NewChannelPomMapWithScheduler[testInfo](newTestScheduler(t, clock, testWorkers))
+	info := testInfo{Title: "Write status", UserID: "TheUser", ChannelID: "TheChannel"}
+
+	_, ok := cpm.Status(Key{ChannelID: info.ChannelID})
+	ExpectedActual(t, false, ok, "status of unknown channel")
+
+	cpm.CreateIfEmpty(Key{ChannelID: info.ChannelID}, DefaultSettings, nil, nil, func(testInfo, Result) {}, info)
+	defer cpm.RemoveIfExists(Key{ChannelID: info.ChannelID}, OutcomeCancelled, "")
+
+	clock.Advance(time.Minute)
+	status, ok := cpm.Status(Key{ChannelID: info.ChannelID})
+	ExpectedActual(t, true, ok, "status of running channel")
+	ExpectedActual(t, info, status.Info, "status info")
+	ExpectedActual(t, PhaseWork, status.Phase, "status phase")
+	ExpectedActual(t, 1, status.Round, "status round")
+	ExpectedActual(t, DefaultSettings.LongBreakInterval, status.Rounds, "status rounds")
+	ExpectedActual(t, testStart, status.Started, "status start")
+	ExpectedActual(t, testStart.Add(DefaultSettings.Work), status.Deadline, "status deadline")
+	ExpectedActual(t, DefaultSettings.Work-time.Minute, status.Remaining, "status remaining")
+}
+
+func TestPomMapUpdate(t *testing.T) {
+	cpm := NewChannelPomMap[testInfo]()
+	key := Key{ChannelID: "TheChannel"}
+	c := make(chan testInfo, 1)
+	join := func(userID string) bool {
+		return cpm.Update(key, func(info *testInfo) bool {
+			if info.UserID == userID || slices.Contains(info.Participants, userID) {
+				return false
+			}
+			info.Participants = append(info.Participants, userID)
+			return true
+		})
+	}
+
+	ExpectedActual(t, false, join("Joiner"), "updating unknown channel")
+
+	cpm.CreateIfEmpty(key, Settings{Work: time.Second}, nil, nil, func(info testInfo, _ Result) { c <- info }, testInfo{UserID: "Owner", ChannelID: key.ChannelID})
+	ExpectedActual(t, false, join("Owner"), "update reporting no change")
+	ExpectedActual(t, true, join("Joiner"), "updating")
+	ExpectedActual(t, false, join("Joiner"), "updating twice")
+
+	status, _ := cpm.Status(key)
+	ExpectedActual(t, []string{"Joiner"}, status.Info.Participants, "status participants")
+
+	cpm.RemoveIfExists(key, OutcomeCancelled, "")
+	info := <-c
+	ExpectedActual(t, []string{"Joiner"}, info.Participants, "participants on end")
+}
+
+// A Pomodoro's payload can be any type, not just a struct
+func TestPomMapOtherPayload(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	cpm := NewChannelPomMapWithScheduler[string](newTestScheduler(t, clock, testWorkers))
+	key := Key{UserID: "cli"}
+	c := make(chan string, 1)
+
+	cpm.CreateIfEmpty(key, Settings{Work: time.Minute * 25}, nil, nil, func(info string, _ Result) { c <- info }, "Write docs")
+	cpm.Update(key, func(info *string) bool {
+		*info += " and tests"
+		return true
+	})
+	clock.Advance(time.Minute * 25)
+	ExpectedActual(t, "Write docs and tests", <-c, "payload on end")
+}
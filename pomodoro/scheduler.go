@@ -0,0 +1,198 @@
+package pomodoro
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// defaultCallbackWorkers is how many callbacks the default Scheduler runs at once.
+const defaultCallbackWorkers = 32
+
+// defaultScheduler runs the Pomodoros that aren't given a Scheduler of their own.
+var defaultScheduler = sync.OnceValue(func() *Scheduler {
+	return NewScheduler(RealClock{}, defaultCallbackWorkers)
+})
+
+// Scheduler runs any number of Pomodoros from a single goroutine, rather than each having its own goroutine and timer.
+// Their wake ups are kept in a min-heap, so only one timer is ever needed, for whichever is soonest. Every change to a
+// Pomodoro's state is made on this goroutine, so Pomodoros need no locks of their own.
+//
+// Callbacks are run by a fixed pool of workers, so a slow callback doesn't hold up any timers. Callbacks that are
+// waiting for a worker are queued in the order they were due, so ones that block for a long time should hand their work
+// off to a goroutine of their own, as the bot does when playing sounds.
+type Scheduler struct {
+	clock Clock
+	ops   chan func() // Operations to run on the scheduler's goroutine, eg creating or pausing a Pomodoro
+	calls chan func() // Callbacks that are ready for a worker
+	queue wakeQueue   // Every Pomodoro that's waiting on a timer, soonest first. Only used on the scheduler's goroutine.
+	ready []func()    // Callbacks waiting to be sent to a worker. Only used on the scheduler's goroutine.
+
+	stop     chan struct{}  // Closed by Stop, to end the scheduler's goroutine
+	stopOnce sync.Once      // Ensures stop is only closed once
+	running  sync.WaitGroup // The scheduler's goroutine and its workers
+}
+
+// NewScheduler creates a Scheduler timed by the clock, and starts it along with the given number of workers to run
+// callbacks. A Scheduler runs until Stop is called.
+func NewScheduler(clock Clock, workers int) *Scheduler {
+	s := &Scheduler{
+		clock: clock,
+		ops:   make(chan func()),
+		calls: make(chan func()),
+		stop:  make(chan struct{}),
+	}
+	s.running.Go(s.run)
+	for range max(workers, 1) {
+		s.running.Go(s.work)
+	}
+
+	return s
+}
+
+// Stop ends the scheduler's goroutine and its workers, waiting for any callbacks that are running to return. Its
+// Pomodoros are never woken again, and callbacks that haven't started yet are dropped. Changes made to its Pomodoros
+// afterwards have no effect.
+//
+// Stop must not be called from a callback, since it would wait on itself.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.running.Wait()
+}
+
+// do runs the op on the scheduler's goroutine, and waits for it to finish. The op isn't run if the scheduler has been
+// stopped.
+func (s *Scheduler) do(op func()) {
+	done := make(chan struct{})
+	select {
+	case s.ops <- func() {
+		op()
+		close(done)
+	}:
+		<-done
+	case <-s.stop:
+	}
+}
+
+// callback queues a callback to be run by a worker. It must only be called on the scheduler's goroutine.
+func (s *Scheduler) callback(call func()) {
+	s.ready = append(s.ready, call)
+}
+
+// wakeAt sets when the entry should be woken, adding it to the queue if it isn't already waiting. It must only be
+// called on the scheduler's goroutine.
+func (s *Scheduler) wakeAt(e *wakeEntry, at time.Time) {
+	e.at = at
+	if e.index < 0 {
+		heap.Push(&s.queue, e)
+	} else {
+		heap.Fix(&s.queue, e.index)
+	}
+}
+
+// unwake removes the entry from the queue, if it's waiting. It must only be called on the scheduler's goroutine.
+func (s *Scheduler) unwake(e *wakeEntry) {
+	if e.index >= 0 {
+		heap.Remove(&s.queue, e.index)
+	}
+}
+
+// run wakes each entry as it becomes due, and runs operations between times, until the scheduler is stopped.
+func (s *Scheduler) run() {
+	var timer Timer
+	var timerAt time.Time
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		// Ends the workers once they've finished their current callbacks
+		close(s.calls)
+	}()
+
+	for {
+		// The timer is only remade when the soonest wake up changes, which most operations don't do
+		if timer != nil && (len(s.queue) == 0 || !s.queue[0].at.Equal(timerAt)) {
+			timer.Stop()
+			timer = nil
+		}
+		if timer == nil && len(s.queue) > 0 {
+			timerAt = s.queue[0].at
+			timer = s.clock.TimerAt(timerAt)
+		}
+
+		var timerChan <-chan time.Time
+		if timer != nil {
+			timerChan = timer.C()
+		}
+		// We can only offer a worker a callback if there's one waiting
+		var calls chan func()
+		var next func()
+		if len(s.ready) > 0 {
+			calls, next = s.calls, s.ready[0]
+		}
+
+		select {
+		case <-timerChan:
+			timer = nil
+			s.wakeDue(s.clock.Now())
+		case op := <-s.ops:
+			op()
+		case calls <- next:
+			s.ready[0] = nil // So the callback can be garbage collected
+			s.ready = s.ready[1:]
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// wakeDue wakes every entry that's due by now, in the order they're due. Entries that are due again straight away, eg
+// after a long delay, are woken again.
+func (s *Scheduler) wakeDue(now time.Time) {
+	for len(s.queue) > 0 && !s.queue[0].at.After(now) {
+		e := heap.Pop(&s.queue).(*wakeEntry)
+		e.wake(now)
+	}
+}
+
+// work runs callbacks as they become ready.
+func (s *Scheduler) work() {
+	for call := range s.calls {
+		call()
+	}
+}
+
+// wakeEntry is something that's waiting in a Scheduler's queue to be woken at a given time.
+type wakeEntry struct {
+	at    time.Time
+	index int                 // The entry's position in the queue, or -1 if it isn't waiting
+	wake  func(now time.Time) // Called on the scheduler's goroutine once the entry is due
+}
+
+// wakeQueue is a min-heap of entries by when they're due, for use with container/heap.
+type wakeQueue []*wakeEntry
+
+func (q wakeQueue) Len() int { return len(q) }
+
+func (q wakeQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+
+func (q wakeQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *wakeQueue) Push(x any) {
+	e := x.(*wakeEntry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *wakeQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	e.index = -1
+	*q = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,219 @@
+package pomodoro
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestSchedulerOrder(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	// With a single worker, callbacks are run one at a time in the order they were due
+	sched := newTestScheduler(t, clock, 1)
+	c := make(chan string, 3)
+	onEnd := func(info string, _ Result) { c <- info }
+
+	startPomodoro(sched, Settings{Work: time.Minute * 3}, nil, nil, onEnd, "third")
+	startPomodoro(sched, Settings{Work: time.Minute}, nil, nil, onEnd, "first")
+	startPomodoro(sched, Settings{Work: time.Minute * 2}, nil, nil, onEnd, "second")
+	clock.Advance(time.Minute * 3)
+
+	ExpectedActual(t, []string{"first", "second", "third"}, []string{<-c, <-c, <-c}, "order Pomodoros ended in")
+	ExpectedActual(t, 0, queueLen(sched), "queued Pomodoros after they've ended")
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	sched := newTestScheduler(t, clock, testWorkers)
+	c := make(chan Result, 1)
+
+	pom := startPomodoro(sched, Settings{Work: time.Minute}, nil, nil, func(_ testInfo, result Result) { c <- result }, testInfo{})
+	ExpectedActual(t, 1, queueLen(sched), "queued Pomodoros while running")
+	pom.Pause()
+	ExpectedActual(t, 0, queueLen(sched), "queued Pomodoros while paused")
+	pom.Resume()
+	pom.Cancel(OutcomeCancelled, "")
+	ExpectedActual(t, 0, queueLen(sched), "queued Pomodoros after cancelling")
+
+	// Nothing is left to fire once it's been cancelled
+	clock.Advance(time.Minute)
+	ExpectedActual(t, OutcomeCancelled, (<-c).Outcome, "outcome of the cancelled Pomodoro")
+	ExpectedActual(t, 0, len(c), "extra results")
+}
+
+// Callbacks run on workers rather than the scheduler's goroutine, so they're free to use other Pomodoros.
+func TestSchedulerCallbackUsesPomodoro(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	sched := newTestScheduler(t, clock, 1)
+	c := make(chan bool)
+
+	other := startPomodoro(sched, Settings{Work: time.Hour}, nil, nil, func(testInfo, Result) {}, testInfo{})
+	startPomodoro(sched, Settings{Work: time.Minute}, nil, nil, func(testInfo, Result) {
+		c <- other.Pause()
+	}, testInfo{})
+	clock.Advance(time.Minute)
+	ExpectedActual(t, true, <-c, "pausing another Pomodoro from a callback")
+}
+
+func TestSchedulerStop(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	sched := NewScheduler(clock, testWorkers)
+	c := make(chan Result, 1)
+
+	pom := startPomodoro(sched, Settings{Work: time.Minute}, nil, nil, func(_ testInfo, result Result) { c <- result }, testInfo{})
+	// Stop only returns once the scheduler's goroutine and workers have exited
+	sched.Stop()
+
+	// Its Pomodoros are left where they were, and can no longer be changed
+	clock.Advance(time.Minute)
+	ExpectedActual(t, false, pom.Pause(), "pausing after stopping")
+	ExpectedActual(t, 0, len(c), "results after stopping")
+	// Stopping twice is harmless
+	sched.Stop()
+}
+
+// queueLen returns how many Pomodoros are waiting in the scheduler's queue.
+func queueLen(sched *Scheduler) int {
+	var n int
+	sched.do(func() { n = len(sched.queue) })
+	return n
+}
+
+// The benchmarks below compare the Scheduler with the design it replaced, where each Pomodoro had its own goroutine and
+// timer. Run them with:
+//
+//	go test -run=NONE -bench=. -benchtime=3x ./pomodoro
+const benchPomodoros = 100_000
+
+// goroutinePom is a cut-down Pomodoro as they were before the Scheduler, with only what's needed to time a single phase.
+type goroutinePom struct {
+	ops    chan func()
+	cancel chan struct{}
+}
+
+func startGoroutinePom(d time.Duration, onEnd func(deadline time.Time)) *goroutinePom {
+	pom := &goroutinePom{ops: make(chan func()), cancel: make(chan struct{})}
+	deadline := time.Now().Add(d)
+	go func() {
+		for {
+			timer := time.NewTimer(time.Until(deadline))
+			select {
+			case <-timer.C:
+				go onEnd(deadline)
+				return
+			case op := <-pom.ops:
+				timer.Stop()
+				op()
+			case <-pom.cancel:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	return pom
+}
+
+// BenchmarkMemory reports the memory used by each running Pomodoro, with benchPomodoros running at once.
+func BenchmarkMemory(b *testing.B) {
+	b.Run("scheduler", func(b *testing.B) {
+		sched := newTestScheduler(b, RealClock{}, defaultCallbackWorkers)
+		poms := make([]*Pomodoro[testInfo], benchPomodoros)
+		measureMemory(b, func(i int) {
+			poms[i] = startPomodoro(sched, Settings{Work: time.Hour}, nil, nil, func(testInfo, Result) {}, testInfo{})
+		}, func(i int) {
+			poms[i].Cancel(OutcomeCancelled, "")
+		})
+	})
+	b.Run("goroutines", func(b *testing.B) {
+		poms := make([]*goroutinePom, benchPomodoros)
+		measureMemory(b, func(i int) {
+			poms[i] = startGoroutinePom(time.Hour, func(time.Time) {})
+		}, func(i int) {
+			close(poms[i].cancel)
+		})
+	})
+}
+
+// measureMemory starts benchPomodoros Pomodoros, and reports how much memory and how many goroutines each needs.
+func measureMemory(b *testing.B, start, cancel func(i int)) {
+	var bytes, goroutines float64
+	for range b.N {
+		before, goroutinesBefore := memInUse(), runtime.NumGoroutine()
+		for i := range benchPomodoros {
+			start(i)
+		}
+		bytes += float64(memInUse() - before)
+		goroutines += float64(runtime.NumGoroutine() - goroutinesBefore)
+
+		b.StopTimer()
+		for i := range benchPomodoros {
+			cancel(i)
+		}
+		b.StartTimer()
+	}
+	b.ReportMetric(bytes/float64(b.N*benchPomodoros), "B/pom")
+	b.ReportMetric(goroutines/float64(b.N*benchPomodoros), "goroutines/pom")
+}
+
+// memInUse returns the heap and stack memory in use after a garbage collection.
+func memInUse() int64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return int64(stats.HeapInuse + stats.StackInuse)
+}
+
+// BenchmarkLatency reports how late Pomodoros end, with benchPomodoros all ending within a second.
+func BenchmarkLatency(b *testing.B) {
+	b.Run("scheduler", func(b *testing.B) {
+		sched := newTestScheduler(b, RealClock{}, defaultCallbackWorkers)
+		measureLatency(b, func(d time.Duration, onEnd func(deadline time.Time)) {
+			startPomodoro(sched, Settings{Work: d}, nil, nil, func(_ testInfo, result Result) {
+				onEnd(result.PhaseStarted.Add(result.Planned))
+			}, testInfo{})
+		})
+	})
+	b.Run("goroutines", func(b *testing.B) {
+		measureLatency(b, func(d time.Duration, onEnd func(deadline time.Time)) {
+			startGoroutinePom(d, onEnd)
+		})
+	})
+}
+
+// measureLatency starts benchPomodoros Pomodoros due to end over the next second, and reports how long after their
+// deadlines their callbacks were called.
+func measureLatency(b *testing.B, start func(d time.Duration, onEnd func(deadline time.Time))) {
+	var late []time.Duration
+	for range b.N {
+		var mutex sync.Mutex
+		var wg sync.WaitGroup
+		wg.Add(benchPomodoros)
+		onEnd := func(deadline time.Time) {
+			lateBy := time.Since(deadline)
+			mutex.Lock()
+			late = append(late, lateBy)
+			mutex.Unlock()
+			wg.Done()
+		}
+
+		// Starting them all takes a while, so they're due a second after the last one starts
+		for i := range benchPomodoros {
+			start(time.Second*time.Duration(i+1)/benchPomodoros+time.Second, onEnd)
+		}
+		wg.Wait()
+	}
+
+	slices.Sort(late)
+	var total time.Duration
+	for _, d := range late {
+		total += d
+	}
+	b.ReportMetric(float64(total.Microseconds())/float64(len(late)), "µs-late-mean")
+	b.ReportMetric(float64(late[len(late)*99/100].Microseconds()), "µs-late-p99")
+	b.ReportMetric(float64(late[len(late)-1].Microseconds()), "µs-late-max")
+}
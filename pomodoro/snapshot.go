@@ -0,0 +1,258 @@
+package pomodoro
+
+import "time"
+
+// Snapshot is the state of a Pomodoro, which can be saved and later used to restore it with RestorePomodoro. This allows
+// Pomodoros to survive a restart of the process running them.
+type Snapshot[T any] struct {
+	Settings  Settings      // The durations and round count for the Pomodoro's cycle
+	Info      T             // The Pomodoro's payload
+	Started   time.Time     // When the Pomodoro was originally started
+	Phase     Phase         // The current phase of the cycle
+	Round     int           // The work round the current phase belongs to, starting at 1
+	Paused    bool          // Whether the Pomodoro is paused
+	Deadline  time.Time     // When the current phase ends. Only used if not paused.
+	Remaining time.Duration // The time left in the current phase. Only used if paused.
+
+	// These were added after the fields above, so snapshots saved by older versions may not have them. If zero, they
+	// are calculated from the settings and deadline.
+	PhaseStarted  time.Time     // When the current phase started
+	PhaseDuration time.Duration // The planned length of the current phase, including any extensions
+	PhaseExtended time.Duration // How much the current phase has been extended by
+	PausedAt      time.Time     // When the Pomodoro was paused. If zero, it's treated as paused from when it's restored.
+	Key           Key           // The key of the Pomodoro in its ChannelPomMap
+}
+
+// FastForward advances the snapshot through any phases that would have ended by the given time. It returns the
+// advanced snapshot, the transitions that were skipped over in order, and whether the whole cycle would have ended.
+// If it has ended, the returned snapshot is of the final phase. The returned transitions are marked as Delayed.
+//
+// Paused snapshots are never advanced.
+func (snap Snapshot[T]) FastForward(now time.Time) (Snapshot[T], []Transition, bool) {
+	st := snap.runState()
+	var missed []Transition
+	for !st.paused && !st.deadline.After(now) {
+		nextPhase, nextRound, ok := snap.Settings.next(st.phase, st.round)
+		if !ok {
+			return snap.withRunState(st), missed, true
+		}
+
+		t := Transition{
+			Ended:    st.phase,
+			Next:     nextPhase,
+			Round:    nextRound,
+			Rounds:   snap.Settings.rounds(),
+			Started:  st.phaseStarted,
+			Duration: st.duration,
+			Extended: st.extended,
+			Delayed:  true,
+		}
+		missed = append(missed, t)
+		st.startPhase(nextPhase, nextRound, st.deadline, snap.Settings.Duration(nextPhase))
+	}
+
+	return snap.withRunState(st), missed, false
+}
+
+// runState returns the state to run the snapshot's Pomodoro from.
+func (snap Snapshot[T]) runState() runState {
+	st := runState{
+		phase:        snap.Phase,
+		round:        snap.Round,
+		phaseStarted: snap.PhaseStarted,
+		duration:     snap.PhaseDuration,
+		extended:     snap.PhaseExtended,
+		deadline:     snap.Deadline,
+		paused:       snap.Paused,
+		pausedAt:     snap.PausedAt,
+		remaining:    snap.Remaining,
+	}
+	if st.duration == 0 {
+		st.duration = snap.Settings.Duration(snap.Phase)
+	}
+	if st.phaseStarted.IsZero() {
+		// Our best guess, which is only wrong if the phase was paused
+		st.phaseStarted = st.deadline.Add(-st.duration)
+	}
+
+	return st
+}
+
+// withRunState returns the snapshot with its state replaced by the given one.
+func (snap Snapshot[T]) withRunState(st runState) Snapshot[T] {
+	snap.Phase, snap.Round = st.phase, st.round
+	snap.PhaseStarted, snap.PhaseDuration, snap.PhaseExtended = st.phaseStarted, st.duration, st.extended
+	snap.Deadline = st.deadline
+	snap.Paused, snap.PausedAt, snap.Remaining = st.paused, st.pausedAt, st.remaining
+	return snap
+}
+
+// WithInfo returns a copy of the snapshot with its payload replaced by the given one. This allows the payload to be
+// converted to and from a form that can be stored.
+func WithInfo[T, U any](snap Snapshot[T], info U) Snapshot[U] {
+	return Snapshot[U]{
+		Settings:      snap.Settings,
+		Info:          info,
+		Started:       snap.Started,
+		Phase:         snap.Phase,
+		Round:         snap.Round,
+		Paused:        snap.Paused,
+		Deadline:      snap.Deadline,
+		Remaining:     snap.Remaining,
+		PhaseStarted:  snap.PhaseStarted,
+		PhaseDuration: snap.PhaseDuration,
+		PhaseExtended: snap.PhaseExtended,
+		PausedAt:      snap.PausedAt,
+		Key:           snap.Key,
+	}
+}
+
+// Result describes the snapshot's Pomodoro ending at the given time with the given outcome, for Pomodoros that can't be
+// restored.
+func (snap Snapshot[T]) Result(outcome Outcome, reason string, now time.Time) Result {
+	st := snap.runState()
+	return st.result(outcome, reason, now)
+}
+
+// RestorePomodoro creates a Pomodoro from the snapshot and starts it. A snapshot with a deadline that has already passed
+// ends its current phase immediately, so callers will usually want to FastForward the snapshot first.
+//
+// Milestones that would have been reached by now are skipped, rather than being reached as soon as it starts.
+func RestorePomodoro[T any](snap Snapshot[T], onPhaseEnd PhaseCallback[T], onMilestone MilestoneCallback[T], onWorkEnd TaskCallback[T]) *Pomodoro[T] {
+	return restorePomodoro(defaultScheduler(), snap, onPhaseEnd, onMilestone, onWorkEnd)
+}
+
+// restorePomodoro creates a Pomodoro run by the scheduler from the snapshot, and starts it. See RestorePomodoro.
+func restorePomodoro[T any](sched *Scheduler, snap Snapshot[T], onPhaseEnd PhaseCallback[T], onMilestone MilestoneCallback[T], onWorkEnd TaskCallback[T]) *Pomodoro[T] {
+	pom := newPomodoro(sched, snap.Settings, onPhaseEnd, onMilestone, onWorkEnd, snap.Info, snap.Started)
+	pom.restore(snap)
+
+	return pom
+}
+
+// restore starts the Pomodoro from the snapshot's state.
+func (pom *Pomodoro[T]) restore(snap Snapshot[T]) {
+	now := pom.sched.clock.Now()
+	st := snap.runState()
+	if st.paused && st.pausedAt.IsZero() {
+		st.pausedAt = now
+	}
+	pom.skipMilestones(&st, now)
+	pom.start(st, true)
+}
+
+// Snapshot returns the current state of the Pomodoro, for use with RestorePomodoro. Returns false if the Pomodoro has
+// ended.
+//
+// This method is goroutine-safe.
+func (pom *Pomodoro[T]) Snapshot() (Snapshot[T], bool) {
+	var snap Snapshot[T]
+	ok := pom.control(func(st *runState) bool {
+		snap = pom.snapshot(*st)
+		return true
+	})
+
+	return snap, ok
+}
+
+// Suspend stops the Pomodoro without ending it, and returns its final state so it can be restored later, eg when the
+// process is shutting down. No callbacks are called and no events are published for it from then on. Returns false if
+// the Pomodoro has already ended.
+//
+// This method is goroutine-safe.
+func (pom *Pomodoro[T]) Suspend() (Snapshot[T], bool) {
+	var snap Snapshot[T]
+	ok := pom.control(func(st *runState) bool {
+		snap = pom.snapshot(*st)
+		pom.ended.Store(true)
+		return true
+	})
+
+	return snap, ok
+}
+
+// snapshot returns a snapshot of the Pomodoro in the given state.
+func (pom *Pomodoro[T]) snapshot(st runState) Snapshot[T] {
+	return Snapshot[T]{
+		Settings: pom.settings,
+		Info:     pom.info,
+		Started:  pom.started,
+	}.withRunState(st)
+}
+
+// Restore will restore the Pomodoro from the snapshot under its key if one does not already exist, similar to
+// CreateIfEmpty. If one does exist, the snapshot's Pomodoro can't carry on, so subscribers are sent EventCancelled with
+// OutcomeRestart for it instead.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Restore(snap Snapshot[T], onPhaseEnd PhaseCallback[T], onMilestone MilestoneCallback[T], onWorkEnd TaskCallback[T]) bool {
+	key := snap.Key
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.runningPom(key) != nil {
+		// Published from the scheduler's goroutine, so it's in order with the events of the Pomodoro in the way
+		m.sched.do(func() {
+			now := m.sched.clock.Now()
+			m.publish(Event[T]{
+				Type:    EventCancelled,
+				Key:     key,
+				Info:    snap.Info,
+				Time:    now,
+				Running: m.running,
+				Result:  snap.Result(OutcomeRestart, "", now),
+			})
+		})
+		return false
+	}
+
+	var pom *Pomodoro[T]
+	pom = newPomodoro(m.sched, snap.Settings, onPhaseEnd, onMilestone, m.doneInMap(onWorkEnd, key, &pom), snap.Info, snap.Started)
+	pom.onEvent = m.publisher(key)
+	pom.restore(snap)
+	m.keyToPom[key] = pom
+	return true
+}
+
+// Snapshots returns the snapshots of all the Pomodoros currently being tracked. They're all taken in a single trip to the
+// scheduler's goroutine, so it stays cheap with many Pomodoros.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Snapshots() []Snapshot[T] {
+	poms := m.all()
+	snaps := make([]Snapshot[T], 0, len(poms))
+	m.sched.do(func() {
+		for key, p := range poms {
+			if p.ended.Load() {
+				continue
+			}
+			snap := p.snapshot(p.st)
+			snap.Key = key
+			snaps = append(snaps, snap)
+		}
+	})
+
+	return snaps
+}
+
+// Drain suspends every Pomodoro in the map, so they can be restored once the process restarts, and returns their
+// snapshots. It then waits for each subscriber to handle the events that were already published, and stops them. The
+// map is left empty and quiet, and shouldn't be used again.
+//
+// This method is goroutine-safe.
+func (m *ChannelPomMap[T]) Drain() []Snapshot[T] {
+	m.mutex.Lock()
+	snaps := make([]Snapshot[T], 0, len(m.keyToPom))
+	for key, p := range m.keyToPom {
+		if snap, ok := p.Suspend(); ok {
+			snap.Key = key
+			snaps = append(snaps, snap)
+		}
+	}
+	clear(m.keyToPom)
+	m.mutex.Unlock()
+
+	m.drainSubscribers()
+	return snaps
+}
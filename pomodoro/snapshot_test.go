@@ -0,0 +1,123 @@
+package pomodoro
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestSnapshotFastForward(t *testing.T) {
+	now := time.Now()
+	snap := Snapshot[testInfo]{
+		Settings: Settings{Work: time.Minute * 25, ShortBreak: time.Minute * 5, LongBreak: time.Minute * 15, LongBreakInterval: 2},
+		Started:  now.Add(-time.Minute * 40),
+		Phase:    PhaseWork,
+		Round:    1,
+		Deadline: now.Add(-time.Minute * 15),
+	}
+
+	// Still running - nothing should change
+	upToDate, missed, ended := snap.FastForward(now.Add(-time.Minute * 20))
+	ExpectedActual(t, snap.Deadline, upToDate.Deadline, "snapshot deadline before the deadline")
+	ExpectedActual(t, snap.Phase, upToDate.Phase, "snapshot phase before the deadline")
+	ExpectedActual(t, 0, len(missed), "missed transitions before the deadline")
+	ExpectedActual(t, false, ended, "ended before the deadline")
+
+	// Work round 1 and the short break have ended, so we should be in work round 2
+	ff, missed, ended := snap.FastForward(now)
+	ExpectedActual(t, false, ended, "ended during round 2")
+	ExpectedActual(t, PhaseWork, ff.Phase, "phase after fast forward")
+	ExpectedActual(t, 2, ff.Round, "round after fast forward")
+	ExpectedActual(t, snap.Deadline.Add(time.Minute*30), ff.Deadline, "deadline after fast forward")
+	ExpectedActual(t, snap.Deadline.Add(time.Minute*5), ff.PhaseStarted, "phase start after fast forward")
+	expectedMissed := []Transition{
+		{Ended: PhaseWork, Next: PhaseShortBreak, Round: 1, Rounds: 2, Started: snap.Started, Duration: time.Minute * 25, Delayed: true},
+		{Ended: PhaseShortBreak, Next: PhaseWork, Round: 2, Rounds: 2, Started: snap.Deadline, Duration: time.Minute * 5, Delayed: true},
+	}
+	ExpectedActual(t, expectedMissed, missed, "missed transitions")
+
+	// Long after the whole cycle should have ended
+	_, _, ended = snap.FastForward(now.Add(time.Hour))
+	ExpectedActual(t, true, ended, "ended long after the cycle")
+
+	// Paused snapshots never advance
+	snap.Paused = true
+	_, missed, ended = snap.FastForward(now.Add(time.Hour))
+	if len(missed) > 0 || ended {
+		t.Error("Expected paused snapshot not to advance")
+	}
+}
+
+func TestPomMapSnapshotRestore(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	cpm := NewChannelPomMapWithScheduler[testInfo](newTestScheduler(t, clock, testWorkers))
+	info := testInfo{Title: "Survive a restart", ChannelID: "TheChannel"}
+	settings := Settings{Work: time.Minute * 25}
+
+	cpm.CreateIfEmpty(Key{ChannelID: info.ChannelID}, settings, nil, nil, func(testInfo, Result) {}, info)
+	clock.Advance(time.Minute * 10)
+	cpm.Pause(Key{ChannelID: info.ChannelID})
+	cpm.Extend(Key{ChannelID: info.ChannelID}, time.Minute*5)
+	snaps := cpm.Snapshots()
+	ExpectedActual(t, 1, len(snaps), "number of snapshots")
+	ExpectedActual(t, info, snaps[0].Info, "snapshot info")
+	ExpectedActual(t, true, snaps[0].Paused, "snapshot paused")
+	ExpectedActual(t, time.Minute*5, snaps[0].PhaseExtended, "snapshot extension")
+	ExpectedActual(t, time.Minute*20, snaps[0].Remaining, "snapshot remaining")
+	ExpectedActual(t, settings, snaps[0].Settings, "snapshot settings")
+	ExpectedActual(t, Key{ChannelID: info.ChannelID}, snaps[0].Key, "snapshot key")
+	ExpectedActual(t, false, cpm.Restore(snaps[0], nil, nil, func(testInfo, Result) {}), "restoring over a running Pomodoro")
+	cpm.RemoveIfExists(Key{ChannelID: info.ChannelID}, OutcomeCancelled, "")
+
+	// Restore into a fresh map, as though we've restarted
+	clock.Advance(time.Hour)
+	restored := NewChannelPomMapWithScheduler[testInfo](newTestScheduler(t, clock, testWorkers))
+	c := make(chan Result)
+	ExpectedActual(t, true, restored.Restore(snaps[0], nil, nil, func(_ testInfo, result Result) { c <- result }), "restoring")
+	status, _ := restored.Status(Key{ChannelID: info.ChannelID})
+	ExpectedActual(t, snaps[0].Started, status.Started, "restored start time")
+	ExpectedActual(t, true, status.Paused, "restored paused")
+
+	ExpectedActual(t, time.Minute*20, status.Remaining, "restored remaining")
+
+	restored.Resume(Key{ChannelID: info.ChannelID})
+	clock.Advance(time.Minute * 20)
+	result := <-c
+	ExpectedActual(t, true, result.Completed(), "restored Pomodoro completion")
+	ExpectedActual(t, time.Minute*5, result.Extended, "restored extension")
+	ExpectedActual(t, 0, restored.Count(), "restored count after completion")
+}
+
+func TestPomMapDrain(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	sched := newTestScheduler(t, clock, testWorkers)
+	cpm := NewChannelPomMapWithScheduler[testInfo](sched)
+	settings := Settings{Work: time.Minute * 25}
+	// A slow subscriber still has every event handled before Drain returns
+	var handled []EventType
+	cpm.Subscribe(func(e Event[testInfo]) {
+		time.Sleep(time.Millisecond)
+		handled = append(handled, e.Type)
+	})
+	ended := make(chan Result, 2)
+	onEnd := func(_ testInfo, result Result) { ended <- result }
+
+	cpm.CreateIfEmpty(Key{ChannelID: "First"}, settings, nil, nil, onEnd, testInfo{ChannelID: "First"})
+	cpm.CreateIfEmpty(Key{ChannelID: "Second"}, settings, nil, nil, onEnd, testInfo{ChannelID: "Second"})
+	cpm.Pause(Key{ChannelID: "Second"})
+	snaps := cpm.Drain()
+	ExpectedActual(t, []EventType{EventStarted, EventStarted, EventPaused}, handled, "events handled by the time it's drained")
+	ExpectedActual(t, 2, len(snaps), "number of snapshots")
+	ExpectedActual(t, 0, cpm.Count(), "count after draining")
+	for _, snap := range snaps {
+		ExpectedActual(t, snap.Info.ChannelID, snap.Key.ChannelID, "snapshot key")
+		ExpectedActual(t, snap.Key.ChannelID == "Second", snap.Paused, "snapshot paused")
+	}
+
+	// Suspended Pomodoros don't end, so they can carry on once restored
+	ExpectedActual(t, 0, queueLen(sched), "queued Pomodoros after draining")
+	clock.Advance(time.Hour)
+	ExpectedActual(t, 0, len(ended), "suspended Pomodoros ending")
+	ExpectedActual(t, 3, len(handled), "events after draining")
+}
@@ -0,0 +1,185 @@
+package coffeebeanbot
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+)
+
+// delayedNote is appended to notifications that should have been sent while the bot wasn't running, eg during a
+// restart or while its host was asleep.
+const delayedNote = "  (delayed while the bot was offline)"
+
+// sessionSaveDelay is how long changes to the sessions wait before being saved, so that changes made together are saved
+// together.
+const sessionSaveDelay = time.Second
+
+// saveSessions snapshots all running Pomodoros to the store, so they can be restored if the bot restarts.
+func (bot *Bot) saveSessions() {
+	bot.sessionsMutex.Lock()
+	defer bot.sessionsMutex.Unlock()
+
+	err := bot.store.SetSessions(bot.toSessions(bot.poms.Snapshots()))
+	LogIfError(bot.logger, err, "Error saving sessions")
+}
+
+// toSessions converts the snapshots to sessions for the store. Any that can't be converted are logged and left out.
+func (bot *Bot) toSessions(snaps []pomSnapshot) []store.Session {
+	sessions := make([]store.Session, 0, len(snaps))
+	for _, snap := range snaps {
+		info, err := json.Marshal(snap.Info)
+		if LogIfError(bot.logger, err, "Error encoding session", "channelID", snap.Info.ChannelID) {
+			continue
+		}
+		sessions = append(sessions, pomodoro.WithInfo(snap, json.RawMessage(info)))
+	}
+	return sessions
+}
+
+// fromSessions converts the sessions from the store back to snapshots. Any that can't be converted are logged and left
+// out.
+func (bot *Bot) fromSessions(sessions []store.Session) []pomSnapshot {
+	snaps := make([]pomSnapshot, 0, len(sessions))
+	for _, session := range sessions {
+		var info NotifyInfo
+		if LogIfError(bot.logger, json.Unmarshal(session.Info, &info), "Error decoding session") {
+			continue
+		}
+		snaps = append(snaps, pomodoro.WithInfo(session, info))
+	}
+	return snaps
+}
+
+// saveOnEvent has the sessions saved whenever a Pomodoro changes in a way that would need restoring. Restored Pomodoros
+// are all saved together once restoreSessions is done, rather than once each.
+func (bot *Bot) saveOnEvent(e pomEvent) {
+	if e.Type == pomodoro.EventMilestone || (e.Type == pomodoro.EventStarted && e.Restored) {
+		return
+	}
+	// If a save is already waiting, it will include this change
+	select {
+	case bot.sessionsChanged <- struct{}{}:
+	default:
+	}
+}
+
+// saveChangedSessions saves the sessions after they change, until stop is closed. Each save writes every session, so
+// changes made within sessionSaveDelay of each other are saved together.
+func (bot *Bot) saveChangedSessions(stop <-chan struct{}) {
+	for {
+		select {
+		case <-bot.sessionsChanged:
+		case <-stop:
+			return
+		}
+
+		select {
+		case <-time.After(sessionSaveDelay):
+			bot.saveSessions()
+		case <-stop:
+			// Any unsaved changes are saved along with the suspended sessions
+			return
+		}
+	}
+}
+
+// suspendSessions stops all running Pomodoros and saves them to the store, so they carry on from where they left off
+// once the bot restarts. Everyone taking part is told so.
+func (bot *Bot) suspendSessions() {
+	// Draining waits for our subscribers, so nothing else will save the sessions after we do
+	snaps := bot.poms.Drain()
+	bot.sessionsMutex.Lock()
+	err := bot.store.SetSessions(bot.toSessions(snaps))
+	bot.sessionsMutex.Unlock()
+	LogIfError(bot.logger, err, "Error saving sessions")
+	// Suspended Pomodoros don't end, so our subscribers never saw them stop running
+	bot.metrics.RecordRunningPoms(0)
+
+	for _, snap := range snaps {
+		bot.inFlight.Go(func() { bot.announceRestart(snap.Info) })
+	}
+	bot.logger.Info("Suspended sessions", "numSessions", len(snaps))
+}
+
+// announceRestart lets everyone taking part in a suspended Pomodoro know it will resume once the bot is back. Nobody is
+// mentioned, since there's nothing they need to do.
+func (bot *Bot) announceRestart(notif NotifyInfo) {
+	message := withTitle(notif.Title, "The bot is restarting.  Your Pomodoro will resume where it left off once it's back.")
+	channelID := notifyChannel(bot.guildSettings(notif.GuildID), notif)
+	_, err := bot.discord.ChannelMessageSend(channelID, message)
+	LogIfError(bot.logger, err, "Error announcing restart", "channelID", channelID)
+}
+
+// restoreSessions restores the Pomodoros that were running when the bot last stopped. Any phases that ended while the
+// bot wasn't running are announced immediately.
+func (bot *Bot) restoreSessions() {
+	sessions, err := bot.store.Sessions()
+	if LogIfError(bot.logger, err, "Error loading sessions") {
+		return
+	}
+	snaps := bot.fromSessions(sessions)
+
+	now := time.Now()
+	for _, snap := range snaps {
+		if snap.Key == (pomodoro.Key{}) {
+			// Saved before Pomodoros had keys, when there was only ever one per channel
+			snap.Key = pomodoro.KeyPerChannel.Key(snap.Info.ChannelID, snap.Info.UserID)
+		}
+		snap, missed, ended := snap.FastForward(now)
+		// Everything that was missed still counts towards the history, but we only announce the latest change
+		for _, t := range missed {
+			bot.recordTransition(snap.Info, t)
+		}
+
+		if ended {
+			if snap.Phase == pomodoro.PhaseWork {
+				bot.addHistory(snap.Info, store.HistoryEntry{
+					Planned:   snap.PhaseDuration - snap.PhaseExtended,
+					Extended:  snap.PhaseExtended,
+					Actual:    snap.PhaseDuration,
+					Completed: true,
+					Outcome:   pomodoro.OutcomeCompleted.String(),
+					Started:   snap.PhaseStarted,
+					Ended:     snap.Deadline,
+				})
+			}
+			bot.metrics.RecordEndPom(pomodoro.OutcomeCompleted.String())
+			bot.notifyUsers(snap.Info, "Pomodoro set complete.  Great work!"+delayedNote)
+			bot.finishLiveStatus(snap.Info, pomodoro.OutcomeCompleted, "")
+			continue
+		}
+
+		if !bot.poms.Restore(snap, nil, nil, nil) {
+			// Something else is already running on the channel, so this one can't carry on. It's ended by the map, so
+			// our subscribers will see it end with OutcomeRestart.
+			continue
+		}
+		if len(missed) == 0 {
+			continue
+		}
+		// Queued with the Pomodoro's events, so it's announced before any changes after it
+		if e, ok := bot.missedEvent(snap, missed[len(missed)-1], now); ok {
+			bot.queueNotify(e)
+		}
+	}
+
+	bot.logger.Info("Restored sessions", "numSessions", len(snaps), "numRunning", bot.poms.Count())
+	bot.saveSessions()
+}
+
+// missedEvent returns the event for the latest phase change that the restored Pomodoro missed while the bot wasn't
+// running. Returns false if the Pomodoro is no longer running.
+func (bot *Bot) missedEvent(snap pomSnapshot, t pomodoro.Transition, now time.Time) (pomEvent, bool) {
+	// The status is needed to tell that it's the latest change, rather than one that was overtaken
+	status, ok := bot.poms.Status(snap.Key)
+	return pomEvent{
+		Type:       pomodoro.EventPhaseChanged,
+		Key:        snap.Key,
+		Info:       snap.Info,
+		Time:       now,
+		Status:     status,
+		Transition: t,
+	}, ok
+}
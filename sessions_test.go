@@ -0,0 +1,88 @@
+package coffeebeanbot
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+// Sessions are stored as JSON, so the bot's payload needs to survive the trip
+func TestSessionsRoundTrip(t *testing.T) {
+	bot := &Bot{logger: slog.New(slog.DiscardHandler)}
+	snaps := []pomSnapshot{{
+		Info:  NotifyInfo{Title: "Persist me", UserID: "TheUser", ChannelID: "TheChannel", Participants: []string{"Joiner"}},
+		Round: 2,
+	}}
+
+	restored := bot.fromSessions(bot.toSessions(snaps))
+	ExpectedActual(t, 1, len(restored), "number of restored snapshots")
+	ExpectedActual(t, snaps[0].Info, restored[0].Info, "restored info")
+	ExpectedActual(t, snaps[0].Round, restored[0].Round, "restored round")
+}
+
+// A phase change missed while the bot was down is announced once its Pomodoro is restored
+func TestMissedEvent(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 9, 0, 0, 0, time.UTC)
+	sched := pomodoro.NewScheduler(pomodoro.NewFakeClock(now), 1)
+	defer sched.Stop()
+	bot := &Bot{poms: pomodoro.NewChannelPomMapWithScheduler[NotifyInfo](sched)}
+
+	snap := pomSnapshot{
+		Settings: pomodoro.DefaultSettings,
+		Info:     NotifyInfo{ChannelID: "TheChannel"},
+		Key:      pomodoro.Key{ChannelID: "TheChannel"},
+		Started:  now.Add(-pomodoro.DefaultSettings.Work - time.Minute*2),
+		Phase:    pomodoro.PhaseWork,
+		Round:    1,
+		Deadline: now.Add(-time.Minute * 2),
+	}
+	snap, missed, _ := snap.FastForward(now)
+	ExpectedActual(t, 1, len(missed), "missed phase changes")
+	ExpectedActual(t, true, bot.poms.Restore(snap, nil, nil, nil), "restoring")
+
+	e, ok := bot.missedEvent(snap, missed[0], now)
+	ExpectedActual(t, true, ok, "missed event of a running Pomodoro")
+	ExpectedActual(t, false, overtaken(e), "missed event overtaken")
+}
+
+// Changes made close together are saved together, once the delay has passed
+func TestSaveChangedSessions(t *testing.T) {
+	dataStore, err := store.NewFileStore(t.TempDir())
+	ExpectedActual(t, nil, err, "opening store")
+	defer dataStore.Close()
+	sched := pomodoro.NewScheduler(pomodoro.NewFakeClock(time.Now()), 1)
+	defer sched.Stop()
+	bot := &Bot{
+		logger:          slog.New(slog.DiscardHandler),
+		store:           dataStore,
+		poms:            pomodoro.NewChannelPomMapWithScheduler[NotifyInfo](sched),
+		sessionsChanged: make(chan struct{}, 1),
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		bot.saveChangedSessions(stop)
+		close(done)
+	}()
+
+	for _, channelID := range []string{"First", "Second"} {
+		bot.poms.CreateIfEmpty(pomodoro.Key{ChannelID: channelID}, pomodoro.DefaultSettings, nil, nil, nil, NotifyInfo{ChannelID: channelID})
+		bot.saveOnEvent(pomEvent{Type: pomodoro.EventStarted})
+	}
+	sessions, _ := dataStore.Sessions()
+	ExpectedActual(t, 0, len(sessions), "sessions saved before the delay")
+
+	deadline := time.Now().Add(sessionSaveDelay * 5)
+	for len(sessions) < 2 && time.Now().Before(deadline) {
+		time.Sleep(sessionSaveDelay / 10)
+		sessions, _ = dataStore.Sessions()
+	}
+	ExpectedActual(t, 2, len(sessions), "sessions saved after the delay")
+
+	close(stop)
+	<-done
+}
@@ -0,0 +1,199 @@
+package coffeebeanbot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/seanpfeifer/coffeebeanbot/store"
+)
+
+const statsEmbedColor = 0x6f4e37 // Coffee brown
+
+// periodStats are the totals for the work rounds that ended within a period.
+type periodStats struct {
+	Completed int           // The number of work rounds completed
+	Focus     time.Duration // The total time spent working, including rounds that were cancelled
+}
+
+// userStats are the statistics shown to a user by /pomstats.
+type userStats struct {
+	Today     periodStats
+	Week      periodStats // Since the start of Monday
+	AllTime   periodStats
+	Streak    int // The number of consecutive days, up to today, with at least one completed work round
+	Cancelled int // The number of work rounds cancelled, all time
+}
+
+// statsAppCmd returns the /pomstats command.
+func statsAppCmd() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        statsCmdName,
+		Description: "Shows your Pomodoro statistics",
+		Type:        discordgo.ChatApplicationCommand,
+		Contexts:    &guildAndDMContexts,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "timezone",
+				Description: "Your time zone, eg America/Los_Angeles, for when your days start. Remembered.",
+				Type:        discordgo.ApplicationCommandOptionString,
+			},
+		},
+	}
+}
+
+func (bot *Bot) onAppCmdStats(s *discordgo.Session, i *discordgo.Interaction) {
+	user := interactionUser(i)
+	if user == nil {
+		return
+	}
+
+	loc, err := bot.userLocation(user.ID, optionString(i.ApplicationCommandData(), "timezone"))
+	if err != nil {
+		respond(s, i, err.Error(), flagEphemeral)
+		return
+	}
+
+	entries, err := bot.store.History(store.HistoryQuery{UserID: user.ID})
+	if LogIfError(bot.logger, err, "Error loading history", "userID", user.ID) {
+		respond(s, i, "Sorry, your statistics couldn't be loaded right now.", flagEphemeral)
+		return
+	}
+
+	s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{statsEmbed(user, loc, computeStats(entries, time.Now(), loc))},
+			Flags:  flagEphemeral,
+		},
+	})
+}
+
+// userLocation returns the time zone to use for the user's statistics. If a timezone name is given it is validated and
+// saved to the user's preferences, otherwise the saved preference is used. Defaults to UTC.
+func (bot *Bot) userLocation(userID, timezone string) (*time.Location, error) {
+	prefs, _, err := bot.store.UserPrefs(userID)
+	LogIfError(bot.logger, err, "Error loading user prefs", "userID", userID)
+
+	if timezone == "" {
+		timezone = prefs.Timezone
+	} else {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return time.UTC, fmt.Errorf("`%s` isn't a time zone I know - try a name like `America/New_York` or `Europe/London`", timezone)
+		}
+		prefs.Timezone = timezone
+		LogIfError(bot.logger, bot.store.SetUserPrefs(userID, prefs), "Error saving user prefs", "userID", userID)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if LogIfError(bot.logger, err, "Error loading saved time zone", "userID", userID, "timezone", timezone) {
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// interactionUser returns the user that triggered the interaction, whether it came from a Guild or a DM.
+func interactionUser(i *discordgo.Interaction) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// computeStats totals the history entries, using loc to decide where days and weeks start.
+func computeStats(entries []store.HistoryEntry, now time.Time, loc *time.Location) userStats {
+	today := startOfDay(now, loc)
+	week := startOfWeek(now, loc)
+
+	stats := userStats{Streak: dailyStreak(entries, now, loc)}
+	for _, entry := range entries {
+		stats.AllTime.add(entry)
+		if !entry.Ended.Before(week) {
+			stats.Week.add(entry)
+		}
+		if !entry.Ended.Before(today) {
+			stats.Today.add(entry)
+		}
+		if !entry.Completed {
+			stats.Cancelled++
+		}
+	}
+
+	return stats
+}
+
+// dailyStreak returns the number of consecutive days, up to today, with at least one completed work round in the
+// entries. A streak isn't broken until the end of today, so if nothing is done yet today it counts from yesterday.
+func dailyStreak(entries []store.HistoryEntry, now time.Time, loc *time.Location) int {
+	completedDays := make(map[time.Time]bool)
+	for _, entry := range entries {
+		if entry.Completed {
+			completedDays[startOfDay(entry.Ended, loc)] = true
+		}
+	}
+
+	day := startOfDay(now, loc)
+	if !completedDays[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for completedDays[day] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+func (p *periodStats) add(entry store.HistoryEntry) {
+	if entry.Completed {
+		p.Completed++
+	}
+	p.Focus += entry.Actual
+}
+
+// startOfWeek returns midnight at the start of the Monday of the week t falls on, in loc.
+func startOfWeek(t time.Time, loc *time.Location) time.Time {
+	day := startOfDay(t, loc)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// startOfDay returns midnight at the start of the day t falls on, in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// statsEmbed renders the user's statistics for display in Discord.
+func statsEmbed(user *discordgo.User, loc *time.Location, stats userStats) *discordgo.MessageEmbed {
+	period := func(name string, p periodStats) *discordgo.MessageEmbedField {
+		return &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  fmt.Sprintf("**%d** pomodoros\n**%.0f** focus minutes", p.Completed, p.Focus.Minutes()),
+			Inline: true,
+		}
+	}
+
+	completion := "No pomodoros yet"
+	if total := stats.AllTime.Completed + stats.Cancelled; total > 0 {
+		completion = fmt.Sprintf("**%.0f%%** (%d completed, %d cancelled)", float64(stats.AllTime.Completed)*100/float64(total), stats.AllTime.Completed, stats.Cancelled)
+	}
+
+	streakDays := "days"
+	if stats.Streak == 1 {
+		streakDays = "day"
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Pomodoro stats for %s", user.Username),
+		Color: statsEmbedColor,
+		Fields: []*discordgo.MessageEmbedField{
+			period("Today", stats.Today),
+			period("This week", stats.Week),
+			period("All time", stats.AllTime),
+			{Name: "Current streak", Value: fmt.Sprintf("**%d** %s", stats.Streak, streakDays), Inline: true},
+			{Name: "Completion rate", Value: completion, Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Days start at midnight in " + loc.String()},
+	}
+}
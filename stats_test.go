@@ -0,0 +1,42 @@
+package coffeebeanbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/store"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+func TestComputeStats(t *testing.T) {
+	loc := time.FixedZone("UTC-8", -8*60*60)
+	// A Wednesday morning
+	now := time.Date(2024, time.March, 13, 9, 0, 0, 0, loc)
+	entry := func(ended time.Time, completed bool, actual time.Duration) store.HistoryEntry {
+		return store.HistoryEntry{Ended: ended, Completed: completed, Actual: actual}
+	}
+
+	entries := []store.HistoryEntry{
+		entry(time.Date(2024, time.March, 4, 12, 0, 0, 0, loc), true, 25*time.Minute),   // Last week, breaking the streak
+		entry(time.Date(2024, time.March, 11, 0, 0, 0, 0, loc), true, 25*time.Minute),   // Monday, at the start of the week
+		entry(time.Date(2024, time.March, 11, 10, 0, 0, 0, loc), false, 10*time.Minute), // Monday, cancelled
+		entry(time.Date(2024, time.March, 12, 23, 0, 0, 0, loc), true, 25*time.Minute),  // Tuesday, but Wednesday in UTC
+		entry(time.Date(2024, time.March, 13, 8, 0, 0, 0, loc), true, 50*time.Minute),   // Today
+	}
+
+	stats := computeStats(entries, now, loc)
+	ExpectedActual(t, periodStats{Completed: 1, Focus: 50 * time.Minute}, stats.Today, "today")
+	ExpectedActual(t, periodStats{Completed: 3, Focus: 110 * time.Minute}, stats.Week, "this week")
+	ExpectedActual(t, periodStats{Completed: 4, Focus: 135 * time.Minute}, stats.AllTime, "all time")
+	ExpectedActual(t, 3, stats.Streak, "streak")
+	ExpectedActual(t, 1, stats.Cancelled, "cancelled")
+
+	// Nothing done yet today doesn't break the streak
+	stats = computeStats(entries[:4], now, loc)
+	ExpectedActual(t, periodStats{}, stats.Today, "today without entries")
+	ExpectedActual(t, 2, stats.Streak, "streak without today")
+
+	// But missing a day does
+	stats = computeStats(entries[:4], now.AddDate(0, 0, 1), loc)
+	ExpectedActual(t, 0, stats.Streak, "broken streak")
+}
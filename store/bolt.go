@@ -0,0 +1,215 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	boltFileName    = "coffeebeanbot.db"
+	boltOpenTimeout = time.Second * 5 // How long to wait for another process to release the database before giving up
+)
+
+var (
+	sessionsBucket = []byte("sessions")
+	historyBucket  = []byte("history")
+	guildsBucket   = []byte("guilds")
+	usersBucket    = []byte("users")
+)
+
+// BoltStore is a Store backed by an embedded bbolt key-value database in a single file. Values are stored as JSON.
+// History entries are keyed by the time they ended, so time range queries only read the entries they need.
+//
+// This is pure Go, so it works in static builds without cgo. This should be created with NewBoltStore().
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (or creates) the database in the given directory, creating the directory if needed.
+func NewBoltStore(dir string) (*BoltStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(filepath.Join(dir, boltFileName), 0o600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{sessionsBucket, historyBucket, guildsBucket, usersBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Close closes the database.
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
+
+// Sessions returns all stored Pomodoro snapshots.
+func (b *BoltStore) Sessions() ([]Session, error) {
+	var sessions []Session
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(_, v []byte) error {
+			var snap Session
+			if err := json.Unmarshal(v, &snap); err != nil {
+				return err
+			}
+			sessions = append(sessions, snap)
+			return nil
+		})
+	})
+
+	return sessions, err
+}
+
+// SetSessions replaces all stored Pomodoro snapshots with the given ones.
+func (b *BoltStore) SetSessions(sessions []Session) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		// Replacing the whole bucket is simpler than working out which sessions have changed
+		if err := tx.DeleteBucket(sessionsBucket); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucket(sessionsBucket)
+		if err != nil {
+			return err
+		}
+
+		for i, snap := range sessions {
+			if err := putJSON(bucket, uint64Key(uint64(i)), snap); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddHistory appends the entry to the ledger.
+func (b *BoltStore) AddHistory(entry HistoryEntry) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket)
+		// The sequence ensures entries that ended at the same instant don't overwrite each other
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		return putJSON(bucket, historyKey(entry.Ended, seq), entry)
+	})
+}
+
+// History returns the entries matching the query, oldest first.
+func (b *BoltStore) History(query HistoryQuery) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	err := b.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(historyBucket).Cursor()
+
+		k, v := cursor.First()
+		if !query.From.IsZero() {
+			k, v = cursor.Seek(historyKey(query.From, 0))
+		}
+
+		for ; k != nil; k, v = cursor.Next() {
+			var entry HistoryEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			// Keys are in time order, so we're done once we pass the end of the range
+			if !query.To.IsZero() && !entry.Ended.Before(query.To) {
+				break
+			}
+
+			if query.Matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		return nil
+	})
+
+	return entries, err
+}
+
+// GuildSettings returns the settings for the given Guild. Returns false if none have been stored.
+func (b *BoltStore) GuildSettings(guildID string) (GuildSettings, bool, error) {
+	var settings GuildSettings
+	exists, err := b.getJSON(guildsBucket, []byte(guildID), &settings)
+	return settings, exists, err
+}
+
+// SetGuildSettings stores the settings for the given Guild, replacing any that already exist.
+func (b *BoltStore) SetGuildSettings(guildID string, settings GuildSettings) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(guildsBucket), []byte(guildID), settings)
+	})
+}
+
+// DeleteGuildSettings removes any settings stored for the given Guild.
+func (b *BoltStore) DeleteGuildSettings(guildID string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(guildsBucket).Delete([]byte(guildID))
+	})
+}
+
+// UserPrefs returns the preferences for the given user. Returns false if none have been stored.
+func (b *BoltStore) UserPrefs(userID string) (UserPrefs, bool, error) {
+	var prefs UserPrefs
+	exists, err := b.getJSON(usersBucket, []byte(userID), &prefs)
+	return prefs, exists, err
+}
+
+// SetUserPrefs stores the preferences for the given user, replacing any that already exist.
+func (b *BoltStore) SetUserPrefs(userID string, prefs UserPrefs) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(usersBucket), []byte(userID), prefs)
+	})
+}
+
+// getJSON decodes the value at the key in the bucket into v. Returns false if the key doesn't exist.
+func (b *BoltStore) getJSON(bucket, key []byte, v any) (bool, error) {
+	exists := false
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get(key)
+		if data == nil {
+			return nil
+		}
+		exists = true
+		return json.Unmarshal(data, v)
+	})
+
+	return exists, err
+}
+
+// putJSON encodes v as JSON and stores it at the key in the bucket.
+func putJSON(bucket *bolt.Bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// uint64Key encodes the number as big-endian, so keys sort numerically.
+func uint64Key(n uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, n)
+}
+
+// historyKey creates a key that sorts by the time, then the sequence number.
+func historyKey(t time.Time, seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(uint64Key(uint64(t.UnixNano())), seq)
+}
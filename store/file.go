@@ -0,0 +1,244 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+const (
+	guildsFileName   = "guilds.json"
+	sessionsFileName = "sessions.json"
+	usersFileName    = "users.json"
+	historyFileName  = "history.jsonl" // JSON Lines, so entries can be appended without rewriting the file
+)
+
+// FileStore is a Store that keeps its data as JSON files within a directory. All data except the history is kept in
+// memory, and the relevant file is rewritten on every change. The history is appended to, and read back in full for
+// each query. This is intended for small deployments, where being able to read and edit the data by hand is more
+// valuable than performance.
+//
+// This should be created with NewFileStore().
+type FileStore struct {
+	mutex    sync.Mutex
+	dir      string
+	guilds   map[string]GuildSettings
+	users    map[string]UserPrefs
+	sessions []Session
+}
+
+// NewFileStore creates a FileStore in the given directory, creating the directory if needed and loading any
+// existing data.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	fileStore := &FileStore{
+		dir:    dir,
+		guilds: make(map[string]GuildSettings),
+		users:  make(map[string]UserPrefs),
+	}
+	if err := loadJSON(filepath.Join(dir, guildsFileName), &fileStore.guilds); err != nil {
+		return nil, err
+	}
+	if err := loadJSON(filepath.Join(dir, sessionsFileName), &fileStore.sessions); err != nil {
+		return nil, err
+	}
+	if err := loadJSON(filepath.Join(dir, usersFileName), &fileStore.users); err != nil {
+		return nil, err
+	}
+
+	return fileStore, nil
+}
+
+// GuildSettings returns the settings for the given Guild. Returns false if none have been stored.
+//
+// This method is goroutine-safe.
+func (f *FileStore) GuildSettings(guildID string) (GuildSettings, bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	settings, exists := f.guilds[guildID]
+	return settings, exists, nil
+}
+
+// SetGuildSettings stores the settings for the given Guild, replacing any that already exist.
+//
+// This method is goroutine-safe.
+func (f *FileStore) SetGuildSettings(guildID string, settings GuildSettings) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.guilds[guildID] = settings
+	return saveJSON(filepath.Join(f.dir, guildsFileName), f.guilds)
+}
+
+// DeleteGuildSettings removes any settings stored for the given Guild.
+//
+// This method is goroutine-safe.
+func (f *FileStore) DeleteGuildSettings(guildID string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, exists := f.guilds[guildID]; !exists {
+		return nil
+	}
+	delete(f.guilds, guildID)
+	return saveJSON(filepath.Join(f.dir, guildsFileName), f.guilds)
+}
+
+// Sessions returns all stored Pomodoro snapshots.
+//
+// This method is goroutine-safe.
+func (f *FileStore) Sessions() ([]Session, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]Session(nil), f.sessions...), nil
+}
+
+// SetSessions replaces all stored Pomodoro snapshots with the given ones.
+//
+// This method is goroutine-safe.
+func (f *FileStore) SetSessions(sessions []Session) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.sessions = append([]Session(nil), sessions...)
+	return saveJSON(filepath.Join(f.dir, sessionsFileName), f.sessions)
+}
+
+// AddHistory appends the entry to the ledger.
+//
+// This method is goroutine-safe.
+func (f *FileStore) AddHistory(entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	file, err := os.OpenFile(filepath.Join(f.dir, historyFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// History returns the entries matching the query, oldest first.
+//
+// This method is goroutine-safe.
+func (f *FileStore) History(query HistoryQuery) ([]HistoryEntry, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	file, err := os.Open(filepath.Join(f.dir, historyFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	decoder := json.NewDecoder(file)
+	for {
+		var entry HistoryEntry
+		err := decoder.Decode(&entry)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if query.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	// Entries are almost always appended in the order they ended, but nothing guarantees it
+	slices.SortStableFunc(entries, func(a, b HistoryEntry) int {
+		return a.Ended.Compare(b.Ended)
+	})
+	return entries, nil
+}
+
+// UserPrefs returns the preferences for the given user. Returns false if none have been stored.
+//
+// This method is goroutine-safe.
+func (f *FileStore) UserPrefs(userID string) (UserPrefs, bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	prefs, exists := f.users[userID]
+	return prefs, exists, nil
+}
+
+// SetUserPrefs stores the preferences for the given user, replacing any that already exist.
+//
+// This method is goroutine-safe.
+func (f *FileStore) SetUserPrefs(userID string, prefs UserPrefs) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.users[userID] = prefs
+	return saveJSON(filepath.Join(f.dir, usersFileName), f.users)
+}
+
+// Close does nothing, as every change is written as it is made.
+func (f *FileStore) Close() error {
+	return nil
+}
+
+// loadJSON decodes the JSON file at path into v. A missing file is not an error, and leaves v untouched.
+func loadJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// saveJSON encodes v as JSON to the file at path. The data is written to a temporary file first, then renamed, so a
+// crash part-way through never leaves a corrupt file behind.
+func saveJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Clean up the temp file if anything fails. After a successful rename this is a no-op.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
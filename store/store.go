@@ -0,0 +1,157 @@
+// Package store contains the persistence layer for the bot's state that needs to survive restarts: the Pomodoros that are
+// running, the history of completed Pomodoros, the settings for each Guild (Discord server) and each user's preferences.
+//
+// Two backends are provided - FileStore, which keeps human-readable JSON files, and BoltStore, which uses an embedded
+// key-value database. Use Open to create the one named in the config.
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+)
+
+// The names of the available backends, for use with Open.
+const (
+	BackendFile = "file"
+	BackendBolt = "bolt"
+)
+
+// Store is the full set of data the bot persists.
+type Store interface {
+	SessionStore
+	HistoryStore
+	GuildSettingsStore
+	UserPrefsStore
+
+	// Close releases any resources held by the store. The store must not be used afterwards.
+	Close() error
+}
+
+// Open creates the Store for the named backend, keeping its data in the given directory. An empty backend name uses
+// BackendFile.
+func Open(backend, dir string) (Store, error) {
+	switch backend {
+	case "", BackendFile:
+		return NewFileStore(dir)
+	case BackendBolt:
+		return NewBoltStore(dir)
+	}
+	return nil, fmt.Errorf("unknown store backend %q", backend)
+}
+
+// Session is the snapshot of one of the bot's running Pomodoros. Its payload is kept as the bot's JSON, so the store doesn't
+// need to know the bot's types.
+type Session = pomodoro.Snapshot[json.RawMessage]
+
+// SessionStore stores the snapshots of the Pomodoros that are currently running, so they can be restored after a restart.
+// Implementations must be goroutine-safe.
+type SessionStore interface {
+	// Sessions returns all stored Pomodoro snapshots.
+	Sessions() ([]Session, error)
+	// SetSessions replaces all stored Pomodoro snapshots with the given ones.
+	SetSessions(sessions []Session) error
+}
+
+// HistoryStore is an append-only ledger of the Pomodoros that have ended.
+// Implementations must be goroutine-safe.
+type HistoryStore interface {
+	// AddHistory appends the entry to the ledger.
+	AddHistory(entry HistoryEntry) error
+	// History returns the entries matching the query, oldest first.
+	History(query HistoryQuery) ([]HistoryEntry, error)
+}
+
+// HistoryEntry records the outcome of a single Pomodoro for a single user.
+type HistoryEntry struct {
+	UserID    string        `json:"userID"`
+	GuildID   string        `json:"guildID"`
+	ChannelID string        `json:"channelID"`
+	Title     string        `json:"title"`     // The title of the work task
+	Planned   time.Duration `json:"planned"`   // The amount of work time that was originally planned
+	Extended  time.Duration `json:"extended"`  // The amount of work time that was added to the plan while running
+	Actual    time.Duration `json:"actual"`    // The amount of work time that was actually done
+	Completed bool          `json:"completed"` // Whether the Pomodoro was completed, rather than cancelled
+	Outcome   string        `json:"outcome"`   // How the work round ended, eg "completed" or "cancelled". Empty in older entries.
+	Reason    string        `json:"reason"`    // The reason given for cancelling, if any
+	Started   time.Time     `json:"started"`
+	Ended     time.Time     `json:"ended"`
+}
+
+// HistoryQuery filters the entries returned by HistoryStore.History. Empty fields match everything.
+type HistoryQuery struct {
+	UserID  string
+	GuildID string
+	From    time.Time // Only entries that ended at or after this time
+	To      time.Time // Only entries that ended before this time
+}
+
+// Matches returns whether the entry matches the query.
+func (q HistoryQuery) Matches(entry HistoryEntry) bool {
+	return (q.UserID == "" || q.UserID == entry.UserID) &&
+		(q.GuildID == "" || q.GuildID == entry.GuildID) &&
+		(q.From.IsZero() || !entry.Ended.Before(q.From)) &&
+		(q.To.IsZero() || entry.Ended.Before(q.To))
+}
+
+// GuildSettingsStore stores the settings for each Guild.
+// Implementations must be goroutine-safe.
+type GuildSettingsStore interface {
+	// GuildSettings returns the settings for the given Guild. Returns false if none have been stored.
+	GuildSettings(guildID string) (GuildSettings, bool, error)
+	// SetGuildSettings stores the settings for the given Guild, replacing any that already exist.
+	SetGuildSettings(guildID string, settings GuildSettings) error
+	// DeleteGuildSettings removes any settings stored for the given Guild.
+	DeleteGuildSettings(guildID string) error
+}
+
+// GuildSettings are the per-Guild settings that can be changed by the Guild's managers.
+type GuildSettings struct {
+	Pomodoro          pomodoro.Settings `json:"pomodoro"`          // The default durations for Pomodoros started in the Guild
+	Mention           bool              `json:"mention"`           // Whether to mention the user(s) when a phase ends
+	MentionRoleID     string            `json:"mentionRoleID"`     // A role to also mention when a phase ends, if set
+	Audio             bool              `json:"audio"`             // Whether to play audio in voice chat when a phase ends
+	AnnounceChannelID string            `json:"announceChannelID"` // The channel to send notifications to, if set. Otherwise the Pomodoro's channel is used.
+	MilestonePost     bool              `json:"milestonePost"`     // Whether to post a notice when a milestone is reached
+	MilestoneAudio    bool              `json:"milestoneAudio"`    // Whether to play the milestone sound in voice chat when a milestone is reached
+	ControlPolicy     string            `json:"controlPolicy"`     // Who can cancel or change a Pomodoro, one of the Control values
+	ModRoleIDs        []string          `json:"modRoleIDs"`        // Roles that can cancel or change any Pomodoro, whatever the ControlPolicy
+	KeyMode           pomodoro.KeyMode  `json:"keyMode"`           // Whether Pomodoros are one per channel, or one per user
+}
+
+// The values of GuildSettings.ControlPolicy. Whatever the policy, members with the Manage Messages permission or one of
+// the ModRoleIDs can cancel or change any Pomodoro.
+const (
+	ControlParticipants = "participants" // Those taking part in it. This is the default, and is also used if unset.
+	ControlStarter      = "starter"      // Only the person who started it
+	ControlAnyone       = "anyone"       // Anyone who can use the commands
+)
+
+// DefaultGuildSettings returns the settings used by Guilds that haven't changed any.
+func DefaultGuildSettings() GuildSettings {
+	return GuildSettings{
+		Pomodoro:       pomodoro.DefaultSettings,
+		Mention:        true,
+		Audio:          true,
+		MilestonePost:  true,
+		MilestoneAudio: true,
+		ControlPolicy:  ControlParticipants,
+	}
+}
+
+// UserPrefsStore stores each user's preferences.
+// Implementations must be goroutine-safe.
+type UserPrefsStore interface {
+	// UserPrefs returns the preferences for the given user. Returns false if none have been stored.
+	UserPrefs(userID string) (UserPrefs, bool, error)
+	// SetUserPrefs stores the preferences for the given user, replacing any that already exist.
+	SetUserPrefs(userID string, prefs UserPrefs) error
+}
+
+// UserPrefs are the preferences each user can set for themselves.
+type UserPrefs struct {
+	Timezone        string `json:"timezone"`        // The IANA name of the user's time zone, used to decide where their days start. UTC if empty.
+	LeaderboardHide bool   `json:"leaderboardHide"` // Whether the user has opted out of appearing on leaderboards
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/seanpfeifer/coffeebeanbot/pomodoro"
+	. "github.com/seanpfeifer/rigging/assert"
+)
+
+// forEachBackend runs the test against every backend. Each run gets its own directory, and an "open" func which
+// opens the store in it. Tests can call "open" again after closing to ensure data survives a restart.
+func forEachBackend(t *testing.T, test func(t *testing.T, open func() Store)) {
+	for _, backend := range []string{BackendFile, BackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			dir := t.TempDir()
+			open := func() Store {
+				s, err := Open(backend, dir)
+				if err != nil {
+					t.Fatalf("Failed to open %s store: %v", backend, err)
+				}
+				return s
+			}
+			test(t, open)
+		})
+	}
+}
+
+func TestOpenUnknownBackend(t *testing.T) {
+	_, err := Open("nope", t.TempDir())
+	if err == nil {
+		t.Error("Expected an error opening an unknown backend")
+	}
+}
+
+func TestGuildSettings(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func() Store) {
+		s := open()
+
+		_, exists, err := s.GuildSettings("TheGuild")
+		ExpectedActual(t, nil, err, "getting unknown guild")
+		ExpectedActual(t, false, exists, "unknown guild exists")
+
+		settings := DefaultGuildSettings()
+		settings.Pomodoro.Work = time.Minute * 50
+		settings.MentionRoleID = "TheRole"
+		ExpectedActual(t, nil, s.SetGuildSettings("TheGuild", settings), "setting guild")
+		ExpectedActual(t, nil, s.Close(), "closing store")
+
+		// Ensure the settings survive reopening
+		s = open()
+		defer s.Close()
+		actual, exists, err := s.GuildSettings("TheGuild")
+		ExpectedActual(t, nil, err, "getting reopened guild")
+		ExpectedActual(t, true, exists, "reopened guild exists")
+		ExpectedActual(t, settings, actual, "reopened guild settings")
+
+		ExpectedActual(t, nil, s.DeleteGuildSettings("TheGuild"), "deleting guild")
+		_, exists, _ = s.GuildSettings("TheGuild")
+		ExpectedActual(t, false, exists, "deleted guild exists")
+	})
+}
+
+func TestSessions(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func() Store) {
+		s := open()
+
+		sessions, err := s.Sessions()
+		ExpectedActual(t, nil, err, "getting empty sessions")
+		ExpectedActual(t, 0, len(sessions), "number of empty sessions")
+
+		// Round to strip the monotonic clock reading, which isn't persisted
+		now := time.Now().Round(0)
+		snaps := []Session{
+			{
+				Settings: pomodoro.DefaultSettings,
+				Info:     json.RawMessage(`{"Title":"Persist me","UserID":"TheUser","GuildID":"TheGuild","ChannelID":"TheChannel"}`),
+				Started:  now,
+				Phase:    pomodoro.PhaseShortBreak,
+				Round:    2,
+				Deadline: now.Add(time.Minute),
+			},
+			{
+				Settings:  pomodoro.DefaultSettings,
+				Info:      json.RawMessage(`{"ChannelID":"TheOtherChannel"}`),
+				Started:   now,
+				Paused:    true,
+				Remaining: time.Minute,
+			},
+		}
+		ExpectedActual(t, nil, s.SetSessions(snaps), "setting sessions")
+		ExpectedActual(t, nil, s.Close(), "closing store")
+
+		s = open()
+		defer s.Close()
+		sessions, err = s.Sessions()
+		ExpectedActual(t, nil, err, "getting reopened sessions")
+		ExpectedActual(t, len(snaps), len(sessions), "number of reopened sessions")
+		for i := range snaps {
+			ExpectedActual(t, true, sessions[i].Started.Equal(now), fmt.Sprintf("session %d start time", i))
+			// The file backend indents the payload along with the rest of the file, so compare it compacted
+			var info bytes.Buffer
+			ExpectedActual(t, nil, json.Compact(&info, sessions[i].Info), fmt.Sprintf("compacting session %d info", i))
+			ExpectedActual(t, string(snaps[i].Info), info.String(), fmt.Sprintf("session %d info", i))
+			ExpectedActual(t, snaps[i].Phase, sessions[i].Phase, fmt.Sprintf("session %d phase", i))
+			ExpectedActual(t, snaps[i].Remaining, sessions[i].Remaining, fmt.Sprintf("session %d remaining", i))
+		}
+
+		// Replacing with fewer sessions should remove the rest
+		ExpectedActual(t, nil, s.SetSessions(snaps[:1]), "replacing sessions")
+		sessions, _ = s.Sessions()
+		ExpectedActual(t, 1, len(sessions), "number of replaced sessions")
+	})
+}
+
+func TestHistory(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func() Store) {
+		s := open()
+		defer s.Close()
+
+		entries, err := s.History(HistoryQuery{})
+		ExpectedActual(t, nil, err, "getting empty history")
+		ExpectedActual(t, 0, len(entries), "number of empty history entries")
+
+		start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+		added := []HistoryEntry{
+			{UserID: "Alice", GuildID: "GuildA", Planned: time.Minute * 25, Actual: time.Minute * 25, Completed: true, Started: start, Ended: start.Add(time.Minute * 25)},
+			{UserID: "Bob", GuildID: "GuildA", Planned: time.Minute * 25, Actual: time.Minute * 10, Started: start, Ended: start.Add(time.Minute * 10)},
+			{UserID: "Alice", GuildID: "GuildB", Planned: time.Minute * 45, Extended: time.Minute * 5, Actual: time.Minute * 50, Completed: true, Started: start.Add(time.Hour), Ended: start.Add(time.Hour + time.Minute*50)},
+		}
+		for i, entry := range added {
+			ExpectedActual(t, nil, s.AddHistory(entry), fmt.Sprintf("adding entry %d", i))
+		}
+
+		type queryCase struct {
+			query    HistoryQuery
+			expected []int // Indices into "added", in the expected order
+		}
+		cases := []queryCase{
+			{HistoryQuery{}, []int{1, 0, 2}},
+			{HistoryQuery{UserID: "Alice"}, []int{0, 2}},
+			{HistoryQuery{GuildID: "GuildA"}, []int{1, 0}},
+			{HistoryQuery{From: start.Add(time.Minute * 25)}, []int{0, 2}},
+			{HistoryQuery{To: start.Add(time.Minute * 25)}, []int{1}},
+			{HistoryQuery{UserID: "Alice", From: start, To: start.Add(time.Hour)}, []int{0}},
+		}
+		for i, c := range cases {
+			entries, err := s.History(c.query)
+			ExpectedActual(t, nil, err, fmt.Sprintf("query %d", i))
+			ExpectedActual(t, len(c.expected), len(entries), fmt.Sprintf("query %d number of entries", i))
+			for j := 0; j < len(c.expected) && j < len(entries); j++ {
+				ExpectedActual(t, true, entries[j].Ended.Equal(added[c.expected[j]].Ended), fmt.Sprintf("query %d entry %d end time", i, j))
+				ExpectedActual(t, added[c.expected[j]].UserID, entries[j].UserID, fmt.Sprintf("query %d entry %d user", i, j))
+			}
+		}
+	})
+}
+
+func TestUserPrefs(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func() Store) {
+		s := open()
+		defer s.Close()
+
+		_, exists, err := s.UserPrefs("TheUser")
+		ExpectedActual(t, nil, err, "getting unknown user")
+		ExpectedActual(t, false, exists, "unknown user exists")
+
+		prefs := UserPrefs{Timezone: "America/Los_Angeles", LeaderboardHide: true}
+		ExpectedActual(t, nil, s.SetUserPrefs("TheUser", prefs), "setting user")
+		actual, exists, err := s.UserPrefs("TheUser")
+		ExpectedActual(t, nil, err, "getting user")
+		ExpectedActual(t, true, exists, "user exists")
+		ExpectedActual(t, prefs, actual, "user prefs")
+	})
+}